@@ -1,6 +1,10 @@
 package main
 
-import "github.com/dwin/fastmask/internal/cli"
+import (
+	"os"
+
+	"github.com/dwin/fastmask/internal/cli"
+)
 
 var (
 	date    string
@@ -9,6 +13,8 @@ var (
 )
 
 func main() {
-	// nolint:errcheck
-	cli.LoadFastmask(date, version, commit).Execute()
+	// Cobra has already printed the error.
+	if err := cli.LoadFastmask(date, version, commit).Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,421 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagOffline = "offline"
+	flagMaxAge  = "max-age"
+
+	cacheFilename    = "cache.db"
+	cacheOpenTimeout = time.Second
+)
+
+var (
+	errCacheEmpty     = errors.New("no cached masked emails, run without --offline first")
+	errChangesStalled = errors.New("masked email changes did not advance")
+
+	cacheMetaKey            = []byte("meta")
+	cacheMaskedEmailsBucket = []byte("maskedEmails")
+)
+
+// maskedEmailCache is a local copy of each profile's masked emails, kept current with
+// MaskedEmail/changes so commands that read masked emails do not fetch all of them every run.
+type maskedEmailCache struct {
+	db      *bolt.DB
+	profile []byte
+}
+
+// cacheMeta describes what the cached masked emails are a copy of.
+type cacheMeta struct {
+	AccountID    string    `json:"accountId"`
+	State        string    `json:"state"`
+	SessionState string    `json:"sessionState"`
+	SyncedAt     time.Time `json:"syncedAt"`
+}
+
+func addCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(flagOffline, false, "Use cached masked emails without contacting Fastmail.")
+	cmd.Flags().String(flagMaxAge, "", "Use cached masked emails without checking for changes if synced within this duration, eg. '10m'.")
+}
+
+func openCache(filename, profile string) (*maskedEmailCache, error) {
+	if err := os.MkdirAll(path.Dir(filename), configDirectoryPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	db, err := bolt.Open(filename, configFilePermissions, &bolt.Options{Timeout: cacheOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+
+	return &maskedEmailCache{db: db, profile: []byte(profile)}, nil
+}
+
+// openCache opens the cache in the state directory for the active profile.
+func (f *fastmask) openCache() (*maskedEmailCache, error) {
+	return openCache(path.Join(f.config.dirs.state, cacheFilename), f.config.profile)
+}
+
+// clearCache removes the cached masked emails of the active profile.
+func (f *fastmask) clearCache() error {
+	cache, err := f.openCache()
+	if err != nil {
+		return err
+	}
+
+	defer cache.Close()
+
+	return cache.clear()
+}
+
+func (c *maskedEmailCache) Close() error {
+	// nolint:wrapcheck // ignore error, nothing to do if close fails.
+	return c.db.Close()
+}
+
+// load returns the cached masked emails, with nil meta if there are none.
+func (c *maskedEmailCache) load() (*cacheMeta, []fastmail.MaskedEmail, error) {
+	var (
+		meta         *cacheMeta
+		maskedEmails []fastmail.MaskedEmail
+	)
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.profile)
+		if b == nil {
+			return nil
+		}
+
+		if v := b.Get(cacheMetaKey); v != nil {
+			meta = &cacheMeta{}
+			if err := json.Unmarshal(v, meta); err != nil {
+				return fmt.Errorf("failed to decode cache: %w", err)
+			}
+		}
+
+		items := b.Bucket(cacheMaskedEmailsBucket)
+		if items == nil {
+			return nil
+		}
+
+		return items.ForEach(func(_, v []byte) error {
+			var m fastmail.MaskedEmail
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("failed to decode cached masked email: %w", err)
+			}
+
+			maskedEmails = append(maskedEmails, m)
+
+			return nil
+		})
+	})
+	if err != nil {
+		// nolint:wrapcheck // errors are wrapped above.
+		return nil, nil, err
+	}
+
+	return meta, maskedEmails, nil
+}
+
+// replace discards the cached masked emails and stores maskedEmails.
+func (c *maskedEmailCache) replace(meta *cacheMeta, maskedEmails []fastmail.MaskedEmail) error {
+	return c.update(func(b *bolt.Bucket) error {
+		if err := b.DeleteBucket(cacheMaskedEmailsBucket); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+
+		return c.write(b, meta, maskedEmails, nil)
+	})
+}
+
+// apply stores the updated masked emails, removes the destroyed IDs and updates meta.
+func (c *maskedEmailCache) apply(meta *cacheMeta, updated []fastmail.MaskedEmail, destroyed []string) error {
+	return c.update(func(b *bolt.Bucket) error {
+		return c.write(b, meta, updated, destroyed)
+	})
+}
+
+// clear removes the cached masked emails of the profile.
+func (c *maskedEmailCache) clear() error {
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(c.profile); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+
+		return nil
+	})
+
+	// nolint:wrapcheck // errors are wrapped above.
+	return err
+}
+
+func (c *maskedEmailCache) update(fn func(b *bolt.Bucket) error) error {
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(c.profile)
+		if err != nil {
+			return fmt.Errorf("failed to update cache: %w", err)
+		}
+
+		return fn(b)
+	})
+
+	// nolint:wrapcheck // errors are wrapped in fn.
+	return err
+}
+
+func (c *maskedEmailCache) write(b *bolt.Bucket, meta *cacheMeta, maskedEmails []fastmail.MaskedEmail, destroyed []string) error {
+	items, err := b.CreateBucketIfNotExists(cacheMaskedEmailsBucket)
+	if err != nil {
+		return fmt.Errorf("failed to update cache: %w", err)
+	}
+
+	for _, id := range destroyed {
+		if err := items.Delete([]byte(id)); err != nil {
+			return fmt.Errorf("failed to update cache: %w", err)
+		}
+	}
+
+	for i := range maskedEmails {
+		v, err := json.Marshal(&maskedEmails[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode masked email: %w", err)
+		}
+
+		if err := items.Put([]byte(maskedEmails[i].ID), v); err != nil {
+			return fmt.Errorf("failed to update cache: %w", err)
+		}
+	}
+
+	v, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := b.Put(cacheMetaKey, v); err != nil {
+		return fmt.Errorf("failed to update cache: %w", err)
+	}
+
+	return nil
+}
+
+// getCachedMaskedEmails returns all masked emails from the cache, brought up to date with the
+// changes since it was last synced unless --offline is set, or it was synced within --max-age.
+// Masked emails are fetched directly if the cache cannot be opened, eg. when locked by another run.
+func (f *fastmask) getCachedMaskedEmails(cmd *cobra.Command) ([]fastmail.MaskedEmail, error) {
+	offline, err := cmd.Flags().GetBool(flagOffline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag %s: %w", flagOffline, err)
+	}
+
+	maxAgeValue, err := cmd.Flags().GetString(flagMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag %s: %w", flagMaxAge, err)
+	}
+
+	var maxAge time.Duration
+
+	if maxAgeValue != "" {
+		if maxAge, err = parseDuration(maxAgeValue); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", flagMaxAge, err)
+		}
+	}
+
+	if offline {
+		cache, err := f.openCache()
+		if err != nil {
+			return nil, err
+		}
+
+		defer cache.Close()
+
+		meta, maskedEmails, err := cache.load()
+		if err != nil {
+			return nil, err
+		}
+
+		if meta == nil {
+			return nil, errCacheEmpty
+		}
+
+		return maskedEmails, nil
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := f.openCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Not using cache: %s\n", err)
+
+		// nolint:wrapcheck // callers wrap errors.
+		return client.GetMaskedEmails(cmd.Context())
+	}
+
+	defer cache.Close()
+
+	return syncCache(cmd.Context(), cache, client, maxAge, time.Now())
+}
+
+// syncCache brings the cache up to date and returns its masked emails. All masked emails are
+// fetched again when the cache is empty, for another account, or the session state changed.
+func syncCache(ctx context.Context, cache *maskedEmailCache, client *fastmail.Client, maxAge time.Duration, now time.Time) ([]fastmail.MaskedEmail, error) {
+	accountID := client.AccountID()
+
+	meta, maskedEmails, err := cache.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if meta != nil && meta.AccountID == accountID && meta.State != "" {
+		if maxAge > 0 && now.Sub(meta.SyncedAt) < maxAge {
+			return maskedEmails, nil
+		}
+
+		synced, err := syncCacheChanges(ctx, cache, client, meta, now)
+		if err != nil && !cannotCalculateChanges(err) {
+			return nil, err
+		}
+
+		if synced {
+			_, maskedEmails, err := cache.load()
+
+			return maskedEmails, err
+		}
+	}
+
+	list, err := client.GetMaskedEmailList(ctx)
+	if err != nil {
+		// nolint:wrapcheck // callers wrap errors.
+		return nil, err
+	}
+
+	meta = &cacheMeta{AccountID: accountID, State: list.State, SessionState: list.SessionState, SyncedAt: now}
+
+	if err := cache.replace(meta, list.List); err != nil {
+		return nil, err
+	}
+
+	return list.List, nil
+}
+
+// syncCacheChanges applies the changes since the cached state, returning false without changing
+// the cache if the session state changed and all masked emails must be fetched again.
+func syncCacheChanges(ctx context.Context, cache *maskedEmailCache, client *fastmail.Client, meta *cacheMeta, now time.Time) (bool, error) {
+	changes, err := getMaskedEmailChanges(ctx, client, meta.State)
+	if err != nil {
+		return false, err
+	}
+
+	if changes.SessionState != meta.SessionState {
+		return false, nil
+	}
+
+	synced := *meta
+	synced.State = changes.State
+	synced.SyncedAt = now
+
+	if err := cache.apply(&synced, changes.Updated, changes.Destroyed); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// cannotCalculateChanges returns true if err means all masked emails must be fetched again.
+func cannotCalculateChanges(err error) bool {
+	var methodErr fastmail.MethodError
+
+	return errors.As(err, &methodErr) && methodErr.Type == fastmail.ErrorTypeCannotCalculateChanges
+}
+
+// maskedEmailChanges are the masked emails changed since a state.
+type maskedEmailChanges struct {
+	State        string
+	SessionState string
+	// Updated are the created and updated masked emails.
+	Updated   []fastmail.MaskedEmail
+	Destroyed []string
+}
+
+// getMaskedEmailChanges returns the masked emails changed since state, fetching every page of
+// changes and then the changed masked emails.
+func getMaskedEmailChanges(ctx context.Context, client *fastmail.Client, state string) (*maskedEmailChanges, error) {
+	changed := map[string]bool{}
+	destroyed := map[string]bool{}
+	result := &maskedEmailChanges{State: state}
+
+	for {
+		changes, err := client.GetMaskedEmailChanges(ctx, result.State)
+		if err != nil {
+			// nolint:wrapcheck // callers wrap errors.
+			return nil, err
+		}
+
+		for _, ids := range [][]string{changes.Created, changes.Updated} {
+			for _, id := range ids {
+				changed[id] = true
+				delete(destroyed, id)
+			}
+		}
+
+		for _, id := range changes.Destroyed {
+			destroyed[id] = true
+			delete(changed, id)
+		}
+
+		// A server reporting more changes without advancing the state would otherwise be asked forever.
+		if changes.HasMoreChanges && changes.NewState == result.State {
+			return nil, fmt.Errorf("%w: more changes reported at state %s", errChangesStalled, result.State)
+		}
+
+		result.State = changes.NewState
+		result.SessionState = changes.SessionState
+
+		if !changes.HasMoreChanges {
+			break
+		}
+	}
+
+	if len(changed) > 0 {
+		ids := make([]string, 0, len(changed))
+		for id := range changed {
+			ids = append(ids, id)
+		}
+
+		list, err := client.GetMaskedEmailList(ctx, ids...)
+		if err != nil {
+			// nolint:wrapcheck // callers wrap errors.
+			return nil, err
+		}
+
+		result.Updated = list.List
+
+		for _, id := range list.NotFound {
+			destroyed[id] = true
+		}
+	}
+
+	for id := range destroyed {
+		result.Destroyed = append(result.Destroyed, id)
+	}
+
+	sort.Strings(result.Destroyed)
+
+	return result, nil
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+// fakeMaskedEmailAPI serves MaskedEmail/get and MaskedEmail/changes from its fields.
+type fakeMaskedEmailAPI struct {
+	state        string
+	sessionState string
+	maskedEmails map[string]fastmail.MaskedEmail
+	changes      map[string]interface{}
+	calls        []string
+	// pushSets are the arguments of each PushSubscription/set call.
+	pushSets []map[string]interface{}
+	// maskedEmailSets are the arguments of each MaskedEmail/set call.
+	maskedEmailSets []map[string]interface{}
+}
+
+func (a *fakeMaskedEmailAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		MethodCalls [][]json.RawMessage `json:"methodCalls"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var (
+		name string
+		args struct {
+			IDs []string `json:"ids"`
+		}
+	)
+
+	_ = json.Unmarshal(request.MethodCalls[0][0], &name)
+	_ = json.Unmarshal(request.MethodCalls[0][1], &args)
+
+	a.calls = append(a.calls, name)
+
+	var payload interface{}
+
+	switch name {
+	case "PushSubscription/set":
+		var set map[string]interface{}
+
+		_ = json.Unmarshal(request.MethodCalls[0][1], &set)
+		a.pushSets = append(a.pushSets, set)
+
+		payload = map[string]interface{}{"created": map[string]interface{}{"fastmask": map[string]string{"id": "push-1"}}}
+	case "MaskedEmail/set":
+		var set map[string]interface{}
+
+		_ = json.Unmarshal(request.MethodCalls[0][1], &set)
+		a.maskedEmailSets = append(a.maskedEmailSets, set)
+
+		updated := map[string]interface{}{}
+		if update, ok := set["update"].(map[string]interface{}); ok {
+			for id := range update {
+				updated[id] = nil
+			}
+		}
+
+		payload = map[string]interface{}{"accountId": "account-1", "updated": updated}
+	case "MaskedEmail/changes":
+		payload = a.changes
+	case "MaskedEmail/get":
+		ids := args.IDs
+		if len(ids) == 0 {
+			for id := range a.maskedEmails {
+				ids = append(ids, id)
+			}
+		}
+
+		list, notFound := []fastmail.MaskedEmail{}, []string{}
+
+		for _, id := range ids {
+			if m, ok := a.maskedEmails[id]; ok {
+				list = append(list, m)
+			} else {
+				notFound = append(notFound, id)
+			}
+		}
+
+		payload = map[string]interface{}{"accountId": "account-1", "state": a.state, "list": list, "notFound": notFound}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"methodResponses": []interface{}{[]interface{}{name, payload, "0"}},
+		"sessionState":    a.sessionState,
+	})
+}
+
+func maskedEmailIDs(maskedEmails []fastmail.MaskedEmail) []string {
+	ids := make([]string, 0, len(maskedEmails))
+	for i := range maskedEmails {
+		ids = append(ids, maskedEmails[i].ID)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func Test_Sync_Cache(t *testing.T) {
+	api := &fakeMaskedEmailAPI{
+		state:        "1",
+		sessionState: "session-1",
+		maskedEmails: map[string]fastmail.MaskedEmail{
+			"masked-1": {ID: "masked-1", Email: "one@fastmail.com", State: fastmail.StateEnabled},
+			"masked-2": {ID: "masked-2", Email: "two@fastmail.com", State: fastmail.StateEnabled},
+		},
+	}
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	apiEndpoint := fastmail.APIEndpoint
+	fastmail.APIEndpoint = server.URL
+
+	defer func() { fastmail.APIEndpoint = apiEndpoint }()
+
+	client := fastmail.NewClient("fastmask").SetTokenAuthCredentials("account-1", "token")
+
+	cache, err := openCache(path.Join(t.TempDir(), cacheFilename), defaultProfile)
+	require.NoError(t, err)
+
+	defer cache.Close()
+
+	ctx := context.TODO()
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// An empty cache fetches all masked emails.
+	maskedEmails, err := syncCache(ctx, cache, client, 0, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"masked-1", "masked-2"}, maskedEmailIDs(maskedEmails))
+	require.Equal(t, []string{"MaskedEmail/get"}, api.calls)
+
+	// Within max age the cache is used as is.
+	api.calls = nil
+	_, err = syncCache(ctx, cache, client, time.Hour, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, api.calls)
+
+	// Changes are applied, fetching only the changed masked emails.
+	api.state = "2"
+	api.maskedEmails["masked-1"] = fastmail.MaskedEmail{ID: "masked-1", Email: "one@fastmail.com", State: fastmail.StateDisabled}
+	api.maskedEmails["masked-3"] = fastmail.MaskedEmail{ID: "masked-3", Email: "three@fastmail.com", State: fastmail.StatePending}
+	delete(api.maskedEmails, "masked-2")
+	api.changes = map[string]interface{}{
+		"accountId": "account-1", "oldState": "1", "newState": "2", "hasMoreChanges": false,
+		"created": []string{"masked-3"}, "updated": []string{"masked-1"}, "destroyed": []string{"masked-2"},
+	}
+
+	api.calls = nil
+	maskedEmails, err = syncCache(ctx, cache, client, time.Hour, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"masked-1", "masked-3"}, maskedEmailIDs(maskedEmails))
+	require.Equal(t, []string{"MaskedEmail/changes", "MaskedEmail/get"}, api.calls)
+
+	meta, cached, err := cache.load()
+	require.NoError(t, err)
+	require.Equal(t, "2", meta.State)
+	require.Equal(t, maskedEmails, cached)
+
+	for _, m := range cached {
+		require.Equal(t, api.maskedEmails[m.ID], m)
+	}
+
+	// A changed session state fetches all masked emails again.
+	api.sessionState = "session-2"
+	api.changes = map[string]interface{}{"accountId": "account-1", "oldState": "2", "newState": "2"}
+
+	api.calls = nil
+	_, err = syncCache(ctx, cache, client, 0, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"MaskedEmail/changes", "MaskedEmail/get"}, api.calls)
+
+	meta, _, err = cache.load()
+	require.NoError(t, err)
+	require.Equal(t, "session-2", meta.SessionState)
+
+	// Another account fetches all masked emails without asking for changes.
+	api.calls = nil
+	_, err = syncCache(ctx, cache, fastmail.NewClient("fastmask").SetTokenAuthCredentials("account-2", "token"), time.Hour, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"MaskedEmail/get"}, api.calls)
+
+	require.NoError(t, cache.clear())
+
+	meta, cached, err = cache.load()
+	require.NoError(t, err)
+	require.Nil(t, meta)
+	require.Empty(t, cached)
+}
+
+func Test_Get_Masked_Email_Changes_Stalled(t *testing.T) {
+	api := &fakeMaskedEmailAPI{
+		changes: map[string]interface{}{"accountId": "account-1", "oldState": "1", "newState": "1", "hasMoreChanges": true},
+	}
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	apiEndpoint := fastmail.APIEndpoint
+	fastmail.APIEndpoint = server.URL
+
+	defer func() { fastmail.APIEndpoint = apiEndpoint }()
+
+	client := fastmail.NewClient("fastmask").SetTokenAuthCredentials("account-1", "token")
+
+	// More changes without a new state is an error rather than an endless loop.
+	_, err := getMaskedEmailChanges(context.TODO(), client, "1")
+	require.ErrorIs(t, err, errChangesStalled)
+	require.Equal(t, []string{"MaskedEmail/changes"}, api.calls)
+}
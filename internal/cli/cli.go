@@ -5,11 +5,14 @@ import (
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
 )
 
 const (
-	flagNoConfirm = "no-confirm"
-	flagConfig    = "config"
+	flagNoConfirm       = "no-confirm"
+	flagConfig          = "config"
+	flagCredentialStore = "credential-store"
 )
 
 type fastmask struct {
@@ -40,7 +43,7 @@ func LoadFastmask(date, version, commit string) *fastmask {
 		Long:             "Un-Official CLI for interacting with Fastmail Masked Emails.\n\nNot endorsed or supported by Fastmail.",
 		TraverseChildren: true,
 		Example: heredoc.Doc(`
-			# Login with Fastmail. This also stores your credentials in a config file at ~/.fastmask/config.yaml.
+			# Login with Fastmail. Credentials are stored in the system keyring, or an encrypted file in the config directory.
 			$ fastmask login -u me@you.com -p abc123 -m 012345 <- MFA is required only if enabled on account.
 
 			# Mask your email address.
@@ -58,22 +61,66 @@ func LoadFastmask(date, version, commit string) *fastmask {
 			`),
 		Version: fmt.Sprintf("%s (commit %s) at %s", version, commit, date),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Arguments and flags are valid by now, so errors such as a cancelled prompt don't print usage.
+			cmd.SilenceUsage = true
+
 			return f.loadConfig()
 		},
 	}
 
 	// Flags
 	cmd.PersistentFlags().BoolP(flagNoConfirm, "y", false, "Disable confirmation prompt.")
+	cmd.PersistentFlags().String(flagConfig, "", "Config file (default is $XDG_CONFIG_HOME/fastmask/config.yaml or ~/.fastmask/config.yaml).")
+	cmd.PersistentFlags().String(flagProfile, "", "Profile to use (default from FASTMASK_PROFILE or config, otherwise 'default'). "+
+		"When set, FASTMASK_ACCOUNT_ID and FASTMASK_ACCESS_TOKEN are ignored.")
+	cmd.PersistentFlags().StringP(flagOutput, "o", "", "Output format: json or table (default from config, otherwise json).")
+	cmd.PersistentFlags().String(flagCredentialStore, "",
+		"Where to store credentials: auto, keyring or file (default from config, otherwise auto).")
 
 	// Sub-Commands
 	cmd.AddCommand(f.loadLoginCmd())
+	cmd.AddCommand(f.loadLogoutCmd())
+	cmd.AddCommand(f.loadWhoamiCmd())
 	cmd.AddCommand(f.loadCreateCmd())
+	cmd.AddCommand(f.loadListCmd())
+	cmd.AddCommand(f.loadShowCmd())
+	cmd.AddCommand(f.loadSearchCmd())
 	cmd.AddCommand(f.loadDeleteCmd())
+	cmd.AddCommand(f.loadRestoreCmd())
+	cmd.AddCommand(f.loadEditCmd())
+	cmd.AddCommand(f.loadPlanCmd())
+	cmd.AddCommand(f.loadApplyCmd())
+	cmd.AddCommand(f.loadExportCmd())
+	cmd.AddCommand(f.loadImportCmd())
+	cmd.AddCommand(f.loadMigrateCmd())
+	cmd.AddCommand(f.loadSnapshotCmd())
+	cmd.AddCommand(f.loadDiffCmd())
+	cmd.AddCommand(f.loadWatchCmd())
+	cmd.AddCommand(f.loadPushReceiverCmd())
+	cmd.AddCommand(f.loadLeaksCmd())
+	cmd.AddCommand(f.loadStatsCmd())
+	cmd.AddCommand(f.loadPolicyCmd())
+	cmd.AddCommand(f.loadTUICmd())
+	cmd.AddCommand(f.loadProfileCmd())
+	cmd.AddCommand(f.loadConfigCmd())
 	cmd.AddCommand(loadLicenseCmd())
 
-	return &fastmask{
-		cmd: cmd,
+	f.cmd = cmd
+
+	return f
+}
+
+// newClient returns a Fastmail client authenticated with the stored credentials.
+func (f *fastmask) newClient() (*fastmail.Client, error) {
+	creds, err := f.config.loadCredentials()
+	if err != nil {
+		return nil, err
 	}
+
+	client := fastmail.NewClient(f.config.AppName)
+	client.SetTokenAuthCredentials(creds.AccountID, creds.AccessToken)
+
+	return client, nil
 }
 
 func (f *fastmask) Execute() error {
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
-	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
@@ -14,53 +14,85 @@ const (
 	appName                    = "fastmask"
 	configFormat               = "yaml"
 	configDirectoryPermissions = 0o700
+
+	configKeyConfig          = "config"
+	configKeyAccountID       = "account_id"
+	configKeyAccessToken     = "access_token"
+	configKeyUsername        = "username"
+	configKeyCredentialStore = "credential_store"
+	configKeyProfile         = "profile"
+	configKeyProfiles        = "profiles"
 )
 
+var errIncompleteEnvCredentials = errors.New("incomplete credentials in environment")
+
 type config struct {
-	v           *viper.Viper
-	AppName     string
-	AppVersion  string
-	accountID   string
-	accessToken string
+	v          *viper.Viper
+	AppName    string
+	AppVersion string
+	dirs       *dirs
+	profile    string
+	output     string
+
+	credentialStoreName string
+	store               credentialStore
+	envCreds            *credentials
 }
 
+// Settings are resolved in order of precedence: flags, FASTMASK_ prefixed environment variables,
+// the config file, and for credentials lastly the credential store.
 func (f *fastmask) loadConfig() error {
-	v := viper.New()
-	v.SetEnvPrefix(appName) // look for env vars prefixed as 'FASTMASK', will be uppercased automatically.
+	dirs, err := resolveDirs()
+	if err != nil {
+		return err
+	}
 
-	configFilepath := v.GetString(flagConfig)
+	if err := dirs.migrateLegacyFiles(); err != nil {
+		return fmt.Errorf("failed to migrate config files: %w", err)
+	}
 
-	if configFilepath != "" {
-		v.SetConfigFile(configFilepath)
-	} else {
-		// If config filepath flag is not set, look for a config
-		// file in home directory and current directory.
-		home, err := homedir.Dir()
-		if err != nil {
-			return fmt.Errorf("failed to determine home directory location: %w", err)
-		}
-		v.SetConfigName("config") // name of config file (without extension)
-		v.SetConfigType(configFormat)
-		v.AddConfigPath(path.Join(home, ".fastmask"))
-	}
-
-	if err := v.ReadInConfig(); err != nil {
-		if errors.Is(err, viper.ConfigFileNotFoundError{}) {
-			// Config file not found; ignore and create.
-			if errCreate := createConfig(); errCreate != nil {
-				return errCreate
-			}
-		} else {
-			return fmt.Errorf("failed to read config file or input: %w", err)
+	configFilepath := f.lookupSetting(nil, flagConfig, configKeyConfig)
+	if configFilepath == "" {
+		configFilepath = dirs.configFile()
+	}
+
+	v := newViper(configFilepath)
+
+	// An explicit --profile selects stored credentials, so those in the environment are not used.
+	var envCreds *credentials
+	if !f.cmd.PersistentFlags().Changed(flagProfile) {
+		if envCreds, err = credentialsFromEnv(); err != nil {
+			return err
 		}
 	}
 
+	// A missing config file is created on save, so it is never created when the
+	// credentials are fully provided by the environment.
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file or input: %w", err)
+	}
+
+	enforceFilePermissions(configFilepath)
+
+	profile := f.selectedProfile(v)
+	if err := validateProfileName(profile); err != nil {
+		return err
+	}
+
+	output, err := f.outputSetting(v)
+	if err != nil {
+		return err
+	}
+
 	config := &config{
 		v:       v,
 		AppName: appName,
 		// AppVersion:  appVersion,
-		accountID:   v.GetString("account_id"),
-		accessToken: v.GetString("access_token"),
+		dirs:                dirs,
+		profile:             profile,
+		output:              output,
+		credentialStoreName: f.lookupSetting(v, flagCredentialStore, configKeyCredentialStore),
+		envCreds:            envCreds,
 	}
 
 	f.config = config
@@ -68,44 +100,187 @@ func (f *fastmask) loadConfig() error {
 	return nil
 }
 
-func (c *config) setAccountID(accountID string) {
-	c.accountID = accountID
-	c.v.Set("account_id", accountID)
+// outputSetting returns the output format, json by default. An invalid format is an error when
+// given by flag, otherwise a warning so a stray environment variable doesn't break every command.
+func (f *fastmask) outputSetting(v *viper.Viper) (string, error) {
+	output := f.lookupSetting(v, flagOutput, configKeyOutput)
+	if output == "" {
+		return outputJSON, nil
+	}
+
+	err := oneOf(outputFormats...)(output)
+	if err == nil {
+		return output, nil
+	}
+
+	if flag := f.cmd.PersistentFlags().Lookup(flagOutput); flag != nil && flag.Changed {
+		return "", err
+	}
+
+	fmt.Fprintf(os.Stderr, "⚠️  Ignoring %s setting, using %s: %v\n", configKeyOutput, outputJSON, err)
+
+	return outputJSON, nil
 }
 
-func (c *config) setAccessToken(accessToken string) {
-	c.accessToken = accessToken
-	c.v.Set("access_token", accessToken)
+// lookupSetting returns the value of a setting from the flag if set, then the FASTMASK_ prefixed
+// environment variable, and then the config file when v is not nil.
+func (f *fastmask) lookupSetting(v *viper.Viper, flagName, key string) string {
+	if flag := f.cmd.PersistentFlags().Lookup(flagName); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+
+	if value := os.Getenv(envName(key)); value != "" {
+		return value
+	}
+
+	if v == nil {
+		return ""
+	}
+
+	return v.GetString(key)
 }
 
-func (c *config) Save() error {
-	if err := c.v.WriteConfig(); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+// envName returns the environment variable name for a config key, eg. 'access_token' is
+// read from FASTMASK_ACCESS_TOKEN.
+func envName(key string) string {
+	return strings.ToUpper(appName + "_" + strings.ReplaceAll(key, ".", "_"))
+}
+
+// credentialsFromEnv returns the credentials from FASTMASK_ACCOUNT_ID and FASTMASK_ACCESS_TOKEN,
+// or nil if neither is set.
+func credentialsFromEnv() (*credentials, error) {
+	creds := &credentials{
+		AccountID:   os.Getenv(envName(configKeyAccountID)),
+		AccessToken: os.Getenv(envName(configKeyAccessToken)),
 	}
 
-	return nil
+	if creds.AccountID == "" && creds.AccessToken == "" {
+		return nil, nil
+	}
+
+	if creds.AccountID == "" || creds.AccessToken == "" {
+		return nil, fmt.Errorf("%w: both %s and %s must be set",
+			errIncompleteEnvCredentials, envName(configKeyAccountID), envName(configKeyAccessToken))
+	}
+
+	return creds, nil
 }
 
-func createConfig() error {
-	home, err := homedir.Dir()
+// credentialStore returns the configured credential store, creating it on first use.
+func (c *config) credentialStore() (credentialStore, error) {
+	if c.store != nil {
+		return c.store, nil
+	}
+
+	store, err := newCredentialStore(c.credentialStoreName, c.dirs.config)
 	if err != nil {
-		return fmt.Errorf("failed to determine home directory location: %w", err)
+		return nil, err
 	}
 
-	directory := path.Join(home, ".fastmask")
+	c.store = store
 
-	if err := os.MkdirAll(directory, configDirectoryPermissions); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	return store, nil
+}
+
+// loadCredentials returns the credentials from the environment if set, otherwise the stored
+// credentials for the active profile, migrating plaintext credentials from the config file into
+// the credential store for the default profile if found.
+func (c *config) loadCredentials() (*credentials, error) {
+	if c.envCreds != nil {
+		return c.envCreds, nil
+	}
+
+	store, err := c.credentialStore()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := store.Get(c.profile)
+	if errors.Is(err, errCredentialsNotFound) {
+		if creds, err = c.migrateLegacyCredentials(store); err != nil || c.profile == defaultProfile {
+			return creds, err
+		}
+
+		return nil, errCredentialsNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials from %s store: %w", store.Name(), err)
 	}
 
-	configPath := path.Join(directory, ".config.yaml")
+	return creds, nil
+}
 
-	f, err := os.Create(configPath)
+// saveCredentials writes the credentials of the active profile to the credential store, records
+// the profile in the config file and removes any plaintext credentials left there.
+func (c *config) saveCredentials(creds *credentials) error {
+	store, err := c.credentialStore()
 	if err != nil {
-		return fmt.Errorf("failed to create config file: %w", err)
+		return err
+	}
+
+	if err := store.Set(c.profile, creds); err != nil {
+		return fmt.Errorf("failed to save credentials to %s store: %w", store.Name(), err)
+	}
+
+	c.v.Set(profileKey(c.profile, configKeyAccountID), creds.AccountID)
+	c.unset(configKeyAccountID)
+	c.unset(configKeyAccessToken)
+
+	return c.Save()
+}
+
+// unset removes key from the config, viper has no way to delete a key so the remaining
+// settings are copied into a new instance.
+func (c *config) unset(key string) {
+	settings := c.v.AllSettings()
+
+	parts := strings.Split(strings.ToLower(key), ".")
+	m := settings
+
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		m = next
+	}
+
+	delete(m, parts[len(parts)-1])
+
+	v := newViper(c.v.ConfigFileUsed())
+
+	// nolint:errcheck // MergeConfigMap only fails on an invalid config type, which is fixed here.
+	v.MergeConfigMap(settings)
+
+	c.v = v
+}
+
+// newViper returns a viper instance for the config file at filename.
+func newViper(filename string) *viper.Viper {
+	v := viper.New()
+	v.SetEnvPrefix(appName) // look for env vars prefixed as 'FASTMASK', will be uppercased automatically.
+	v.SetConfigType(configFormat)
+	v.SetConfigFile(filename)
+	v.SetConfigPermissions(configFilePermissions)
+
+	return v
+}
+
+// Save writes the config file, creating it and its directory if needed.
+func (c *config) Save() error {
+	filename := c.v.ConfigFileUsed()
+
+	if err := os.MkdirAll(path.Dir(filename), configDirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if err := c.v.WriteConfig(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
-	defer f.Close()
+	enforceFilePermissions(filename)
 
 	return nil
 }
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var errSettingNotSet = errors.New("setting is not set")
+
+func (f *fastmask) loadConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and edit settings.",
+		Long:  "Inspect and edit settings in the config file. Secret values are masked on display.",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file.",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			fmt.Println(f.config.v.ConfigFileUsed())
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a setting.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  f.runConfigGet,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting.",
+		Long:  "Change a setting. Known settings:\n\n" + knownSettingsHelp(),
+		Args:  cobra.ExactArgs(2), // nolint:gomnd // key and value.
+		RunE:  f.runConfigSet,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a setting.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  f.runConfigUnset,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List all settings in the config file.",
+		Args:  cobra.NoArgs,
+		RunE:  f.runConfigList,
+	})
+
+	return cmd
+}
+
+func knownSettingsHelp() string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, s := range knownSettings {
+		fmt.Fprintf(w, "  %s\t%s\n", s.key, s.description)
+	}
+
+	w.Flush()
+
+	return b.String()
+}
+
+func (f *fastmask) runConfigGet(_ *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+
+	if !f.config.v.IsSet(key) {
+		return fmt.Errorf("%w: '%s'", errSettingNotSet, key)
+	}
+
+	fmt.Println(displayValue(key, f.config.v.Get(key)))
+
+	return nil
+}
+
+func (f *fastmask) runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := strings.ToLower(args[0]), args[1]
+
+	s, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+
+	if s.validate != nil {
+		if err := s.validate(value); err != nil {
+			return err
+		}
+	}
+
+	// The active profile must exist, as with 'fastmask profile use'.
+	if key == configKeyProfile {
+		return f.runProfileUse(cmd, args[1:])
+	}
+
+	f.config.v.Set(key, value)
+
+	return f.config.Save()
+}
+
+func (f *fastmask) runConfigUnset(_ *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+
+	if !f.config.v.IsSet(key) {
+		return fmt.Errorf("%w: '%s'", errSettingNotSet, key)
+	}
+
+	f.config.unset(key)
+
+	return f.config.Save()
+}
+
+func (f *fastmask) runConfigList(_ *cobra.Command, _ []string) error {
+	settings := map[string]interface{}{}
+	flattenSettings("", f.config.v.AllSettings(), settings)
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key, displayValue(key, settings[key]))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+// flattenSettings copies nested settings into out using dotted keys, eg. 'profiles.work.username'.
+func flattenSettings(prefix string, settings, out map[string]interface{}) {
+	for key, value := range settings {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenSettings(key, nested, out)
+
+			continue
+		}
+
+		out[key] = value
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHome points HOME at a temporary directory with no XDG or FASTMASK_ variables set, returning
+// its path.
+func setupHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+
+	for _, env := range []string{
+		envXDGConfigHome, envXDGStateHome, envXDGCacheHome,
+		envName(configKeyConfig), envName(configKeyProfile), envName(configKeyAccountID), envName(configKeyAccessToken),
+	} {
+		t.Setenv(env, "")
+	}
+
+	return home
+}
+
+// loadTestConfig loads the config as a command run with the persistent flags in args.
+func loadTestConfig(t *testing.T, args ...string) (*fastmask, error) {
+	t.Helper()
+
+	f := LoadFastmask("", "", "")
+	require.NoError(t, f.cmd.PersistentFlags().Parse(args))
+
+	return f, f.loadConfig()
+}
+
+func Test_Config_File_Precedence(t *testing.T) {
+	home := setupHome(t)
+
+	f, err := loadTestConfig(t)
+	require.NoError(t, err)
+	require.Equal(t, path.Join(home, legacyDirname, configFilename), f.config.v.ConfigFileUsed())
+
+	envConfig := path.Join(home, "env.yaml")
+	t.Setenv(envName(configKeyConfig), envConfig)
+
+	f, err = loadTestConfig(t)
+	require.NoError(t, err)
+	require.Equal(t, envConfig, f.config.v.ConfigFileUsed())
+
+	flagConfig := path.Join(home, "flag.yaml")
+
+	f, err = loadTestConfig(t, "--config", flagConfig)
+	require.NoError(t, err)
+	require.Equal(t, flagConfig, f.config.v.ConfigFileUsed())
+}
+
+func Test_Env_Credentials(t *testing.T) {
+	t.Run("Incomplete", func(t *testing.T) {
+		setupHome(t)
+		t.Setenv(envName(configKeyAccountID), "u1234")
+
+		_, err := loadTestConfig(t)
+		require.ErrorIs(t, err, errIncompleteEnvCredentials)
+	})
+
+	t.Run("No Config File Created", func(t *testing.T) {
+		home := setupHome(t)
+		t.Setenv(envName(configKeyAccountID), "u1234")
+		t.Setenv(envName(configKeyAccessToken), "token")
+
+		f, err := loadTestConfig(t)
+		require.NoError(t, err)
+
+		creds, err := f.config.loadCredentials()
+		require.NoError(t, err)
+		require.Equal(t, &credentials{AccountID: "u1234", AccessToken: "token"}, creds)
+
+		_, err = os.Stat(path.Join(home, legacyDirname))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Ignored With Profile Flag", func(t *testing.T) {
+		setupHome(t)
+		t.Setenv(envName(configKeyAccountID), "u1234")
+		t.Setenv(envName(configKeyAccessToken), "token")
+
+		f, err := loadTestConfig(t, "--profile", "work")
+		require.NoError(t, err)
+		require.Equal(t, "work", f.config.profile)
+		require.Nil(t, f.config.envCreds)
+	})
+}
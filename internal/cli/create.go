@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,6 +15,14 @@ import (
 const (
 	flagDescription = "description"
 	flagDisabled    = "disabled"
+
+	// createBatchSize is the number of masked emails created per request.
+	createBatchSize = 50
+
+	createStatusCreated = "created"
+	createStatusFailed  = "failed"
+	// createStatusExisting is the status of masked emails that already existed, eg. created by an earlier run.
+	createStatusExisting = "existing"
 )
 
 func (f *fastmask) loadCreateCmd() *cobra.Command {
@@ -44,18 +56,24 @@ func (f *fastmask) runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get flag %s: %w", flagDescription, err)
 	}
 
+	if description == "" {
+		description = expandDescription(f.config.v.GetString(configKeyDefaultDescription), domain, time.Now())
+	}
+
 	m := fastmail.MaskedEmail{
 		ForDomain:   domain,
 		Description: description,
 	}
 
-	client := fastmail.NewClient(f.config.AppName)
-	client.SetTokenAuthCredentials(f.config.accountID, f.config.accessToken)
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
 
 	resp, err := client.CreateMaskedEmail(cmd.Context(), &m, !enabled) // must invert disabled to enabled
 	if err != nil {
 		if reauthNeeded(err) {
-			fmt.Println("🛑 Authentication failed. Please run 'fastmask auth'.")
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
 
 			return nil
 		}
@@ -63,5 +81,70 @@ func (f *fastmask) runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create masked email: %w", err)
 	}
 
-	return writeOutput(resp)
+	return f.writeOutput(resp)
+}
+
+// createResult is the result of creating one masked email of a batch.
+type createResult struct {
+	MaskedEmail fastmail.MaskedEmail
+	// Error is set if the masked email could not be created.
+	Error string
+	// Existing is set if the masked email already existed and was not created.
+	Existing bool
+}
+
+func (r *createResult) status() string {
+	if r.Existing {
+		return createStatusExisting
+	}
+
+	if r.MaskedEmail.ID == "" {
+		return createStatusFailed
+	}
+
+	return createStatusCreated
+}
+
+// createMaskedEmailsInBatches creates the masked emails, createBatchSize per request, returning a
+// result for each in the same order. Creating continues after a failed batch, the first error is returned.
+func createMaskedEmailsInBatches(ctx context.Context, client *fastmail.Client, maskedEmails []*fastmail.MaskedEmail) ([]createResult, error) {
+	results := make([]createResult, len(maskedEmails))
+
+	var firstErr error
+
+	for start := 0; start < len(maskedEmails); start += createBatchSize {
+		end := start + createBatchSize
+		if end > len(maskedEmails) {
+			end = len(maskedEmails)
+		}
+
+		creates := make(map[string]*fastmail.MaskedEmail, end-start)
+		for i := start; i < end; i++ {
+			creates[fmt.Sprintf("c%d", i)] = maskedEmails[i]
+		}
+
+		created, err := client.CreateMaskedEmails(ctx, creates)
+
+		var setErrs fastmail.SetErrors
+		errors.As(err, &setErrs)
+
+		for i := start; i < end; i++ {
+			creationID := fmt.Sprintf("c%d", i)
+
+			switch m, ok := created[creationID]; {
+			case ok:
+				results[i].MaskedEmail = m
+			case setErrs[creationID].Type != "":
+				results[i].Error = strings.TrimSpace(setErrs[creationID].Type + " " + setErrs[creationID].Description)
+			case err != nil:
+				results[i].Error = err.Error()
+			}
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to create masked emails: %w", err)
+		}
+	}
+
+	return results, firstErr
 }
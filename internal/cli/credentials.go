@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	credentialStoreAuto    = "auto"
+	credentialStoreKeyring = "keyring"
+	credentialStoreFile    = "file"
+
+	credentialsFilename = "credentials.enc"
+)
+
+var (
+	errCredentialsNotFound        = errors.New("no stored credentials found, please run 'fastmask login'")
+	errUnknownCredentialStore     = errors.New("unknown credential store")
+	errCredentialStoreUnavailable = errors.New("credential store unavailable")
+)
+
+// credentials are the values needed to authenticate with the Fastmail API.
+type credentials struct {
+	AccountID   string `json:"account_id"`
+	AccessToken string `json:"access_token"`
+}
+
+// credentialStore persists credentials outside of the plaintext config file.
+type credentialStore interface {
+	// Name returns the name of the store as used by the 'credential_store' setting.
+	Name() string
+	// Get returns the credentials stored for key, or errCredentialsNotFound.
+	Get(key string) (*credentials, error)
+	// Set stores the credentials for key, replacing any existing value.
+	Set(key string, creds *credentials) error
+	// Delete removes the credentials for key, it is not an error if none are stored.
+	Delete(key string) error
+}
+
+// newCredentialStore returns the credential store with the given name, 'auto' will use the system
+// keyring when available and fall back to the encrypted file store in dir.
+func newCredentialStore(name, dir string) (credentialStore, error) {
+	switch strings.ToLower(name) {
+	case "", credentialStoreAuto:
+		if keyringAvailable() {
+			return newKeyringCredentialStore(), nil
+		}
+
+		return newFileCredentialStore(path.Join(dir, credentialsFilename), promptPassphrase), nil
+	case credentialStoreKeyring:
+		if !keyringAvailable() {
+			return nil, fmt.Errorf("%w: %s", errCredentialStoreUnavailable, credentialStoreKeyring)
+		}
+
+		return newKeyringCredentialStore(), nil
+	case credentialStoreFile:
+		return newFileCredentialStore(path.Join(dir, credentialsFilename), promptPassphrase), nil
+	default:
+		return nil, fmt.Errorf("%w: '%s', expected one of: %s, %s, %s",
+			errUnknownCredentialStore, name, credentialStoreAuto, credentialStoreKeyring, credentialStoreFile)
+	}
+}
+
+// migrateLegacyCredentials moves a plaintext account ID and access token from the config file
+// into the credential store under the default profile, which they were saved for before profiles
+// existed. Returns errCredentialsNotFound if there is nothing to migrate.
+func (c *config) migrateLegacyCredentials(store credentialStore) (*credentials, error) {
+	creds := &credentials{
+		AccountID:   c.v.GetString(configKeyAccountID),
+		AccessToken: c.v.GetString(configKeyAccessToken),
+	}
+
+	if creds.AccessToken == "" {
+		return nil, errCredentialsNotFound
+	}
+
+	if err := store.Set(defaultProfile, creds); err != nil {
+		return nil, fmt.Errorf("failed to migrate credentials to %s store: %w", store.Name(), err)
+	}
+
+	c.v.Set(profileKey(defaultProfile, configKeyAccountID), creds.AccountID)
+	c.unset(configKeyAccountID)
+	c.unset(configKeyAccessToken)
+
+	if err := c.Save(); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "🔐 Moved plaintext credentials from config file to %s credential store.\n", store.Name())
+
+	return creds, nil
+}
@@ -0,0 +1,345 @@
+package cli
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	envPassphrase = "FASTMASK_PASSPHRASE"
+
+	credentialsFileVersion     = 1
+	credentialsFilePermissions = 0o600
+	credentialsKDF             = "scrypt"
+	credentialsCipher          = "aes-256-gcm"
+
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+var (
+	errPassphraseRequired = errors.New("passphrase required, set " + envPassphrase + " or run interactively")
+	errPassphraseMismatch = errors.New("passphrases do not match")
+	errDecryptCredentials = errors.New("failed to decrypt credentials, wrong passphrase?")
+)
+
+// passphraseFunc returns the passphrase used to encrypt the credentials file, confirm is true
+// when a new file is being created and the passphrase should be entered twice.
+type passphraseFunc func(confirm bool) ([]byte, error)
+
+// encryptedCredentialsFile is the on-disk format of the file credential store.
+type encryptedCredentialsFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Cipher     string `json:"cipher"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// fileCredentialStore stores credentials in a file encrypted with a key derived from a passphrase
+// using scrypt and sealed with AES-256-GCM.
+type fileCredentialStore struct {
+	path       string
+	passphrase passphraseFunc
+	cached     []byte
+}
+
+func newFileCredentialStore(path string, passphrase passphraseFunc) *fileCredentialStore {
+	return &fileCredentialStore{
+		path:       path,
+		passphrase: passphrase,
+	}
+}
+
+func (s *fileCredentialStore) Name() string {
+	return credentialStoreFile
+}
+
+func (s *fileCredentialStore) Get(key string) (*credentials, error) {
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, ok := entries[key]
+	if !ok {
+		return nil, errCredentialsNotFound
+	}
+
+	return creds, nil
+}
+
+func (s *fileCredentialStore) Set(key string, creds *credentials) error {
+	entries, err := s.read()
+	if err != nil && !errors.Is(err, errCredentialsNotFound) {
+		return err
+	}
+
+	if entries == nil {
+		entries = map[string]*credentials{}
+	}
+
+	entries[key] = creds
+
+	return s.write(entries)
+}
+
+func (s *fileCredentialStore) Delete(key string) error {
+	entries, err := s.read()
+	if errors.Is(err, errCredentialsNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := entries[key]; !ok {
+		return nil
+	}
+
+	delete(entries, key)
+
+	if len(entries) == 0 {
+		if err := os.Remove(s.path); err != nil {
+			return fmt.Errorf("failed to remove credentials file: %w", err)
+		}
+
+		return nil
+	}
+
+	return s.write(entries)
+}
+
+// getPassphrase returns the cached passphrase, or asks for it once per invocation.
+func (s *fileCredentialStore) getPassphrase(confirm bool) ([]byte, error) {
+	if s.cached != nil {
+		return s.cached, nil
+	}
+
+	passphrase, err := s.passphrase(confirm)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cached = passphrase
+
+	return passphrase, nil
+}
+
+func (s *fileCredentialStore) read() (map[string]*credentials, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errCredentialsNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var file encryptedCredentialsFile
+
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+
+	if file.Version != credentialsFileVersion || file.KDF != credentialsKDF || file.Cipher != credentialsCipher {
+		return nil, fmt.Errorf("unsupported credentials file format: version %d, kdf %s, cipher %s", file.Version, file.KDF, file.Cipher)
+	}
+
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(passphrase, file.Salt, file.N, file.R, file.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		// Drop the cached passphrase so a retry will ask again.
+		s.cached = nil
+
+		return nil, errDecryptCredentials
+	}
+
+	var entries map[string]*credentials
+
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted credentials: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (s *fileCredentialStore) write(entries map[string]*credentials) error {
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	passphrase, err := s.getPassphrase(true)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedCredentialsFile{
+		Version: credentialsFileVersion,
+		KDF:     credentialsKDF,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Cipher:  credentialsCipher,
+		Salt:    make([]byte, saltLen),
+	}
+
+	if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := scrypt.Key(passphrase, file.Salt, file.N, file.R, file.P, scryptKeyLen)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, aead.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+
+	b, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials file: %w", err)
+	}
+
+	return writeFileAtomic(s.path, b, credentialsFilePermissions)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	return aead, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to filename and renames it into place.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+
+	if err := os.MkdirAll(dir, configDirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return nil
+}
+
+// promptPassphrase reads the passphrase from FASTMASK_PASSPHRASE or the terminal without echo.
+func promptPassphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(envPassphrase); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+
+	if !term.IsTerminal(fd) {
+		return nil, errPassphraseRequired
+	}
+
+	fmt.Fprint(os.Stderr, "Credential store passphrase: ")
+
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errPassphraseRequired
+	}
+
+	if !confirm {
+		return passphrase, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+
+	confirmation, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	if string(passphrase) != string(confirmation) {
+		return nil, errPassphraseMismatch
+	}
+
+	return passphrase, nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/icrowley/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_File_Credential_Store(t *testing.T) {
+	filename := path.Join(t.TempDir(), credentialsFilename)
+	passphrase := []byte(fake.CharactersN(16))
+
+	staticPassphrase := func(_ bool) ([]byte, error) {
+		return passphrase, nil
+	}
+
+	creds := &credentials{
+		AccountID:   fake.CharactersN(9),
+		AccessToken: fake.CharactersN(40),
+	}
+
+	t.Run("Get - No File", func(t *testing.T) {
+		store := newFileCredentialStore(filename, staticPassphrase)
+
+		result, err := store.Get(defaultProfile)
+		require.ErrorIs(t, err, errCredentialsNotFound)
+		require.Nil(t, result)
+	})
+
+	t.Run("Set and Get", func(t *testing.T) {
+		require.NoError(t, newFileCredentialStore(filename, staticPassphrase).Set(defaultProfile, creds))
+
+		info, err := os.Stat(filename)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(credentialsFilePermissions), info.Mode().Perm())
+
+		b, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		require.NotContains(t, string(b), creds.AccessToken, "access token must not be stored in plaintext")
+
+		result, err := newFileCredentialStore(filename, staticPassphrase).Get(defaultProfile)
+		require.NoError(t, err)
+		require.Equal(t, creds, result)
+	})
+
+	t.Run("Get - Wrong Passphrase", func(t *testing.T) {
+		store := newFileCredentialStore(filename, func(_ bool) ([]byte, error) {
+			return []byte("wrong"), nil
+		})
+
+		result, err := store.Get(defaultProfile)
+		require.ErrorIs(t, err, errDecryptCredentials)
+		require.Nil(t, result)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newFileCredentialStore(filename, staticPassphrase)
+
+		require.NoError(t, store.Delete(defaultProfile))
+
+		_, err := os.Stat(filename)
+		require.ErrorIs(t, err, os.ErrNotExist, "credentials file should be removed when empty")
+
+		require.NoError(t, store.Delete(defaultProfile), "deleting missing credentials is not an error")
+	})
+}
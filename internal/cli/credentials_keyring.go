@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+const keyringProbeUser = "fastmask-probe"
+
+// keyringCredentialStore stores credentials in the system keyring, Secret Service on Linux,
+// Keychain on macOS and Credential Manager on Windows.
+type keyringCredentialStore struct {
+	service string
+}
+
+func newKeyringCredentialStore() *keyringCredentialStore {
+	return &keyringCredentialStore{service: appName}
+}
+
+// keyringAvailable reports whether the system keyring can be reached.
+func keyringAvailable() bool {
+	_, err := keyring.Get(appName, keyringProbeUser)
+
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+func (s *keyringCredentialStore) Name() string {
+	return credentialStoreKeyring
+}
+
+func (s *keyringCredentialStore) Get(key string) (*credentials, error) {
+	secret, err := keyring.Get(s.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, errCredentialsNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials from keyring: %w", err)
+	}
+
+	var creds credentials
+
+	if err := json.Unmarshal([]byte(secret), &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials from keyring: %w", err)
+	}
+
+	return &creds, nil
+}
+
+func (s *keyringCredentialStore) Set(key string, creds *credentials) error {
+	b, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	if err := keyring.Set(s.service, key, string(b)); err != nil {
+		return fmt.Errorf("failed to write credentials to keyring: %w", err)
+	}
+
+	return nil
+}
+
+func (s *keyringCredentialStore) Delete(key string) error {
+	if err := keyring.Delete(s.service, key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete credentials from keyring: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Migrate_Legacy_Credentials(t *testing.T) {
+	dir := t.TempDir()
+
+	v := newViper(path.Join(dir, "config.yaml"))
+	v.Set(configKeyAccountID, "u1234")
+	v.Set(configKeyAccessToken, "token")
+
+	store := newFileCredentialStore(path.Join(dir, credentialsFilename), func(_ bool) ([]byte, error) {
+		return []byte("passphrase"), nil
+	})
+	c := &config{v: v, dirs: &dirs{config: dir}, profile: "work", store: store}
+
+	// Legacy credentials belong to the default profile, not the active one.
+	_, err := c.loadCredentials()
+	require.ErrorIs(t, err, errCredentialsNotFound)
+
+	creds, err := store.Get(defaultProfile)
+	require.NoError(t, err)
+	require.Equal(t, &credentials{AccountID: "u1234", AccessToken: "token"}, creds)
+	require.Equal(t, "u1234", c.v.GetString(profileKey(defaultProfile, configKeyAccountID)))
+	require.Empty(t, c.v.GetString(configKeyAccessToken))
+
+	_, err = store.Get("work")
+	require.ErrorIs(t, err, errCredentialsNotFound)
+}
@@ -4,67 +4,175 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/dwin/fastmask/pkg/fastmail"
 )
 
+const (
+	flagDryRun = "dry-run"
+	flagSoft   = "soft"
+	flagPurge  = "purge"
+)
+
+var (
+	ErrOperationCancelled = errors.New("operation canceled")
+
+	errNoSelection   = errors.New("no masked emails selected, pass IDs or a selector flag")
+	errSoftWithPurge = errors.New("--soft=true and --purge can not be used together")
+)
+
 func (f *fastmask) loadDeleteCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "delete <id>...",
+		Use:   "delete [id|email]...",
 		Short: "Delete masked emails.",
-		Long:  "Delete masked email addresses.",
-		RunE:  f.delete,
+		Long: "Delete masked email addresses by ID or email, or by selector flags. The affected masked " +
+			"emails are shown before confirming.\n\n" +
+			"By default masked emails are soft deleted, set to the deleted state, and can be recovered with " +
+			"'fastmask restore'. Use --purge to destroy them permanently.",
+		Example: "  fastmask delete masked-12345678\n  fastmask delete --state pending --dry-run\n" +
+			"  fastmask delete --domain '*.example.com' --inactive-for 365d\n  fastmask delete --state deleted --purge",
+		RunE: f.delete,
 	}
 
-	cmd.Args = cobra.MinimumNArgs(1)
+	addFilterFlags(cmd)
+	cmd.Flags().Bool(flagDryRun, false, "Show the masked emails that would be deleted without deleting them.")
+	cmd.Flags().Bool(flagSoft, true, "Set masked emails to the deleted state, they can be restored later. --soft=false is the same as --purge.")
+	cmd.Flags().Bool(flagPurge, false, "Permanently destroy masked emails, they can not be restored.")
 
 	return cmd
 }
 
-var ErrOperationCancelled = errors.New("operation canceled")
+func (f *fastmask) delete(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && !filterFlagsChanged(cmd) {
+		return errNoSelection
+	}
 
-func confirmDelete(cmd *cobra.Command, _ []string) error {
-	skipConfirm, err := cmd.Flags().GetBool(flagNoConfirm)
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
 	if err != nil {
-		return fmt.Errorf("failed to get flag %s: %w", flagNoConfirm, err)
+		return fmt.Errorf("failed to get flag %s: %w", flagDryRun, err)
 	}
 
-	if skipConfirm {
-		return nil
+	purge, err := cmd.Flags().GetBool(flagPurge)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagPurge, err)
 	}
 
-	fmt.Printf("Confirm deletion of masked emails: (y/N): ")
+	soft, err := cmd.Flags().GetBool(flagSoft)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagSoft, err)
+	}
 
-	var confirm string
+	if purge && soft && cmd.Flags().Changed(flagSoft) {
+		return errSoftWithPurge
+	}
 
-	fmt.Scanln(&confirm)
+	// --soft=false is the same as --purge.
+	purge = purge || !soft
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	selected, err := selectMaskedEmails(cmd, maskedEmails, args)
+	if err != nil {
+		return err
+	}
+
+	// Masked emails already soft deleted are only affected when purging.
+	if !purge {
+		selected = excludeState(selected, fastmail.StateDeleted)
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No masked emails matched.")
 
-	if s := strings.ToLower(confirm); s == "y" || s == "yes" {
 		return nil
 	}
 
-	fmt.Println("Deletion canceled.")
-	os.Exit(0)
+	if err := writeMaskedEmailTable(os.Stdout, selected); err != nil {
+		return err
+	}
 
-	return nil
-}
+	action := "deleted"
+	if purge {
+		action = "permanently destroyed"
+	}
 
-func (f *fastmask) delete(cmd *cobra.Command, args []string) error {
-	if err := confirmDelete(cmd, args); err != nil {
+	if dryRun {
+		fmt.Printf("\nDry run, %d masked emails would be %s.\n", len(selected), action)
+
+		return nil
+	}
+
+	ok, err := confirm(cmd, fmt.Sprintf("\nConfirm %d masked emails will be %s", len(selected), action))
+	if err != nil {
 		return err
 	}
 
-	client := fastmail.NewClient(f.config.AppName)
-	client.SetTokenAuthCredentials(f.config.accountID, f.config.accessToken)
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	ids := make([]string, 0, len(selected))
+	for i := range selected {
+		ids = append(ids, selected[i].ID)
+	}
+
+	if purge {
+		err = client.DeleteMaskedEmails(cmd.Context(), ids...)
+	} else {
+		err = client.SetMaskedEmailsState(cmd.Context(), fastmail.StateDeleted, ids...)
+	}
 
-	if err := client.DeleteMaskedEmails(cmd.Context(), args...); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to delete masked emails: %w", err)
 	}
 
-	fmt.Println("Masked emails deleted.")
+	fmt.Printf("%d masked emails %s.\n", len(ids), action)
+
+	if !purge {
+		fmt.Println("Use 'fastmask restore <id>' to recover them.")
+	}
 
 	return nil
 }
+
+// keepState returns the masked emails that are in the given state.
+func keepState(maskedEmails []fastmail.MaskedEmail, state string) []fastmail.MaskedEmail {
+	result := make([]fastmail.MaskedEmail, 0, len(maskedEmails))
+
+	for i := range maskedEmails {
+		if maskedEmails[i].State == state {
+			result = append(result, maskedEmails[i])
+		}
+	}
+
+	return result
+}
+
+// excludeState returns the masked emails that are not in the given state.
+func excludeState(maskedEmails []fastmail.MaskedEmail, state string) []fastmail.MaskedEmail {
+	result := make([]fastmail.MaskedEmail, 0, len(maskedEmails))
+
+	for i := range maskedEmails {
+		if maskedEmails[i].State != state {
+			result = append(result, maskedEmails[i])
+		}
+	}
+
+	return result
+}
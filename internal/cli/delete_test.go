@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Keep_State(t *testing.T) {
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", State: fastmail.StateEnabled},
+		{ID: "masked-2", State: fastmail.StateDeleted},
+		{ID: "masked-3", State: fastmail.StateDisabled},
+		{ID: "masked-4", State: fastmail.StatePending},
+	}
+
+	// Restore only selects deleted masked emails, never disabled or pending ones.
+	require.Equal(t, []string{"masked-2"}, maskedEmailIDs(keepState(maskedEmails, fastmail.StateDeleted)))
+	require.Equal(t, []string{"masked-1", "masked-3", "masked-4"}, maskedEmailIDs(excludeState(maskedEmails, fastmail.StateDeleted)))
+}
+
+// runDeleteCmd runs the delete command against api, answering any prompt with answer.
+func runDeleteCmd(t *testing.T, api *fakeMaskedEmailAPI, answer string, args ...string) error {
+	t.Helper()
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	apiEndpoint := fastmail.APIEndpoint
+	fastmail.APIEndpoint = server.URL
+
+	defer func() { fastmail.APIEndpoint = apiEndpoint }()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	_, err = w.WriteString(answer + "\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	stdin := os.Stdin
+	os.Stdin = r
+
+	defer func() { os.Stdin = stdin }()
+
+	f := &fastmask{config: &config{AppName: appName, envCreds: &credentials{AccountID: "account-1", AccessToken: "token"}}}
+
+	cmd := f.loadDeleteCmd()
+	cmd.Flags().BoolP(flagNoConfirm, "y", false, "")
+	cmd.SetArgs(append([]string{}, args...))
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+
+	// nolint:wrapcheck // the command error is checked as is.
+	return cmd.ExecuteContext(context.TODO())
+}
+
+// updatedIDs returns the sorted IDs of the masked emails updated by a MaskedEmail/set call.
+func updatedIDs(set map[string]interface{}) []string {
+	update, _ := set["update"].(map[string]interface{})
+
+	ids := make([]string, 0, len(update))
+	for id := range update {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func Test_Delete(t *testing.T) {
+	newAPI := func() *fakeMaskedEmailAPI {
+		return &fakeMaskedEmailAPI{
+			state: "1",
+			maskedEmails: map[string]fastmail.MaskedEmail{
+				"masked-1": {ID: "masked-1", ForDomain: "https://example.com", State: fastmail.StateEnabled, LastMessageAt: "2020-01-01T00:00:00Z"},
+				"masked-2": {ID: "masked-2", ForDomain: "https://shop.example.com", State: fastmail.StatePending},
+				"masked-3": {ID: "masked-3", ForDomain: "https://example.com", State: fastmail.StateDeleted},
+				"masked-4": {ID: "masked-4", ForDomain: "https://other.com", State: fastmail.StateEnabled, LastMessageAt: "2020-01-01T00:00:00Z"},
+				"masked-5": {ID: "masked-5", ForDomain: "https://example.com", State: fastmail.StateEnabled, LastMessageAt: "2999-01-01T00:00:00Z"},
+			},
+		}
+	}
+
+	t.Run("Selectors", func(t *testing.T) {
+		api := newAPI()
+
+		require.NoError(t, runDeleteCmd(t, api, "", "-y", "--domain", "*example.com"))
+		require.Equal(t, []string{"MaskedEmail/get", "MaskedEmail/set"}, api.calls)
+		require.Equal(t, []string{"masked-1", "masked-2", "masked-5"}, updatedIDs(api.maskedEmailSets[0]))
+
+		api = newAPI()
+
+		require.NoError(t, runDeleteCmd(t, api, "", "-y", "--state", "enabled", "--inactive-for", "365d"))
+		require.Equal(t, []string{"masked-1", "masked-4"}, updatedIDs(api.maskedEmailSets[0]))
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		api := newAPI()
+
+		require.NoError(t, runDeleteCmd(t, api, "", "--state", "enabled", "--dry-run"))
+		require.Equal(t, []string{"MaskedEmail/get"}, api.calls)
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		api := newAPI()
+
+		require.ErrorIs(t, runDeleteCmd(t, api, "n", "masked-1"), ErrOperationCancelled)
+		require.Equal(t, []string{"MaskedEmail/get"}, api.calls)
+	})
+
+	t.Run("No Selection", func(t *testing.T) {
+		require.ErrorIs(t, runDeleteCmd(t, newAPI(), ""), errNoSelection)
+	})
+}
@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	defaultEditor = "vi"
+
+	editTableColumns = 5
+)
+
+var (
+	errEditorNotSet   = errors.New("no editor found, set $VISUAL or $EDITOR")
+	errInvalidEditRow = errors.New("invalid row")
+
+	// editableStates are the states a masked email can be set to, pending masked emails can only
+	// be left pending.
+	editableStates = []string{fastmail.StateEnabled, fastmail.StateDisabled, fastmail.StateDeleted}
+)
+
+func (f *fastmask) loadEditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit [id|email]...",
+		Short: "Bulk edit masked emails in your editor.",
+		Long: "Bulk edit the state, domain, description and url of masked emails in $VISUAL or $EDITOR. " +
+			"The selected masked emails, or all masked emails if none are selected, are written as a tab " +
+			"separated table. After saving, the changed fields are shown for confirmation and applied in " +
+			"a single request. Removing a row leaves that masked email unchanged.",
+		Example: "  fastmask edit --domain '*.example.com'\n  EDITOR=nano fastmask edit --state pending",
+		RunE:    f.runEdit,
+	}
+
+	addFilterFlags(cmd)
+
+	return cmd
+}
+
+func (f *fastmask) runEdit(cmd *cobra.Command, args []string) error {
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	selected, err := selectMaskedEmails(cmd, maskedEmails, args)
+	if err != nil {
+		return err
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No masked emails matched.")
+
+		return nil
+	}
+
+	edited, err := editInEditor(selected)
+	if err != nil {
+		return err
+	}
+
+	updates, err := parseEditTable(strings.NewReader(edited), selected)
+	if err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		fmt.Println("No changes.")
+
+		return nil
+	}
+
+	writeEditSummary(os.Stdout, selected, updates)
+
+	ok, err := confirm(cmd, fmt.Sprintf("\nConfirm updating %d masked emails", len(updates)))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	if err := client.UpdateMaskedEmails(cmd.Context(), updates); err != nil {
+		return fmt.Errorf("failed to update masked emails: %w", err)
+	}
+
+	fmt.Printf("%d masked emails updated.\n", len(updates))
+
+	return nil
+}
+
+// editInEditor writes the masked emails to a temporary file, opens it in the user's editor and
+// returns the saved content.
+func editInEditor(maskedEmails []fastmail.MaskedEmail) (string, error) {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+
+	if len(editor) == 0 {
+		if _, err := exec.LookPath(defaultEditor); err != nil {
+			return "", errEditorNotSet
+		}
+
+		editor = []string{defaultEditor}
+	}
+
+	file, err := os.CreateTemp("", appName+"-edit-*.tsv")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer os.Remove(file.Name())
+
+	if err := writeEditTable(file, maskedEmails); err != nil {
+		file.Close()
+
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// nolint:gosec // running the user's own editor is the point.
+	c := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("failed to run editor %s: %w", editor[0], err)
+	}
+
+	b, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read temporary file: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// writeEditTable writes the editable fields of the masked emails as a tab separated table.
+func writeEditTable(out io.Writer, maskedEmails []fastmail.MaskedEmail) error {
+	w := bufio.NewWriter(out)
+
+	fmt.Fprintln(w, "# Edit the STATE, DOMAIN, DESCRIPTION and URL columns, columns are separated by tabs.")
+	fmt.Fprintf(w, "# STATE is one of: %s. Removed rows are left unchanged.\n", strings.Join(editableStates, ", "))
+	fmt.Fprintln(w, "# ID\tSTATE\tDOMAIN\tDESCRIPTION\tURL")
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.ID, m.State, tableCell(m.ForDomain), tableCell(m.Description), tableCell(m.URL))
+	}
+
+	// nolint:wrapcheck // ignore error, the caller reports failing to write the file.
+	return w.Flush()
+}
+
+// parseEditTable parses a table written by writeEditTable and returns the changed fields of each
+// masked email, keyed by ID.
+func parseEditTable(r io.Reader, maskedEmails []fastmail.MaskedEmail) (map[string]fastmail.MaskedEmailUpdate, error) {
+	byID := make(map[string]*fastmail.MaskedEmail, len(maskedEmails))
+	for i := range maskedEmails {
+		byID[maskedEmails[i].ID] = &maskedEmails[i]
+	}
+
+	updates := map[string]fastmail.MaskedEmailUpdate{}
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != editTableColumns {
+			return nil, fmt.Errorf("%w on line %d: expected %d tab separated columns, got %d",
+				errInvalidEditRow, n, editTableColumns, len(fields))
+		}
+
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		id, state, domain, description, url := fields[0], strings.ToLower(fields[1]), fields[2], fields[3], fields[4]
+
+		original, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w on line %d: unknown masked email ID '%s'", errInvalidEditRow, n, id)
+		}
+
+		if seen[id] {
+			return nil, fmt.Errorf("%w on line %d: masked email ID '%s' is listed more than once", errInvalidEditRow, n, id)
+		}
+
+		seen[id] = true
+
+		var update fastmail.MaskedEmailUpdate
+
+		if state != original.State {
+			if oneOf(editableStates...)(state) != nil {
+				return nil, fmt.Errorf("%w on line %d: %s: '%s', expected one of: %s",
+					errInvalidEditRow, n, errInvalidState, state, strings.Join(editableStates, ", "))
+			}
+
+			update.State = &state
+		}
+
+		// Compare with the values as written, so values containing tabs or newlines are not changed
+		// unless they were edited.
+		if domain != strings.TrimSpace(tableCell(original.ForDomain)) {
+			update.ForDomain = &domain
+		}
+
+		if description != strings.TrimSpace(tableCell(original.Description)) {
+			update.Description = &description
+		}
+
+		if url != strings.TrimSpace(tableCell(original.URL)) {
+			update.URL = &url
+		}
+
+		if update != (fastmail.MaskedEmailUpdate{}) {
+			updates[id] = update
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read edited table: %w", err)
+	}
+
+	return updates, nil
+}
+
+// writeEditSummary writes the changed fields of each updated masked email.
+func writeEditSummary(out io.Writer, maskedEmails []fastmail.MaskedEmail, updates map[string]fastmail.MaskedEmailUpdate) {
+	ids := make([]string, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	byID := make(map[string]*fastmail.MaskedEmail, len(maskedEmails))
+	for i := range maskedEmails {
+		byID[maskedEmails[i].ID] = &maskedEmails[i]
+	}
+
+	for _, id := range ids {
+		m, update := byID[id], updates[id]
+
+		fmt.Fprintf(out, "%s (%s)\n", m.Email, m.ID)
+		writeEditSummaryFields(out, m, update)
+	}
+}
+
+// writeEditSummaryFields writes the old and new value of each field changed by the update.
+func writeEditSummaryFields(out io.Writer, m *fastmail.MaskedEmail, update fastmail.MaskedEmailUpdate) {
+	for _, change := range []struct {
+		name     string
+		old      string
+		newValue *string
+	}{
+		{"state", m.State, update.State},
+		{"domain", m.ForDomain, update.ForDomain},
+		{"description", m.Description, update.Description},
+		{"url", m.URL, update.URL},
+	} {
+		if change.newValue != nil {
+			fmt.Fprintf(out, "  %s: %q -> %q\n", change.name, change.old, *change.newValue)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Edit_Table(t *testing.T) {
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "one@fastmail.com", State: "enabled", ForDomain: "example.com", Description: "line one\nline two"},
+		{ID: "masked-2", Email: "two@fastmail.com", State: "pending", ForDomain: "example.org", URL: "https://example.org"},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeEditTable(&buf, maskedEmails))
+
+	t.Run("Unchanged", func(t *testing.T) {
+		updates, err := parseEditTable(strings.NewReader(buf.String()), maskedEmails)
+		require.NoError(t, err)
+		require.Empty(t, updates, "values sanitized for the table are not changes")
+	})
+
+	t.Run("Changed Fields Only", func(t *testing.T) {
+		edited := strings.Replace(buf.String(), "masked-1\tenabled\texample.com", "masked-1\tdisabled\texample.com", 1)
+		edited = strings.Replace(edited, "https://example.org", "https://shop.example.org", 1)
+
+		updates, err := parseEditTable(strings.NewReader(edited), maskedEmails)
+		require.NoError(t, err)
+		require.Len(t, updates, 2)
+
+		require.Equal(t, "disabled", *updates["masked-1"].State)
+		require.Nil(t, updates["masked-1"].Description)
+		require.Equal(t, "https://shop.example.org", *updates["masked-2"].URL)
+		require.Nil(t, updates["masked-2"].State)
+
+		var summary bytes.Buffer
+
+		writeEditSummary(&summary, maskedEmails, updates)
+		require.Equal(t, "one@fastmail.com (masked-1)\n  state: \"enabled\" -> \"disabled\"\n"+
+			"two@fastmail.com (masked-2)\n  url: \"https://example.org\" -> \"https://shop.example.org\"\n", summary.String())
+	})
+
+	t.Run("Removed Rows Unchanged", func(t *testing.T) {
+		updates, err := parseEditTable(strings.NewReader("masked-2\tdeleted\texample.org\t\thttps://example.org\n"), maskedEmails)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.Equal(t, "deleted", *updates["masked-2"].State)
+	})
+
+	t.Run("Invalid Rows", func(t *testing.T) {
+		for name, table := range map[string]string{
+			"columns":   "masked-1\tenabled\n",
+			"unknown":   "masked-9\tenabled\texample.com\t\t\n",
+			"duplicate": "masked-2\tpending\texample.org\t\t\nmasked-2\tpending\texample.org\t\t\n",
+			"state":     "masked-1\tpending\texample.com\t\t\n",
+		} {
+			_, err := parseEditTable(strings.NewReader(table), maskedEmails)
+			require.ErrorIs(t, err, errInvalidEditRow, name)
+		}
+	})
+}
@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagFormat = "format"
+
+	exportCSV          = "csv"
+	exportJSON         = "json"
+	exportBitwarden    = "bitwarden"
+	exportKeePassXML   = "keepass-xml"
+	export1PasswordCSV = "1password-csv"
+
+	exportFilePermissions = 0o600
+
+	// bitwardenTypeLogin is the Bitwarden item type of logins.
+	bitwardenTypeLogin = 1
+)
+
+var (
+	errUnknownExportFormat = errors.New("unknown export format")
+
+	exportFormats = []string{exportCSV, exportJSON, exportBitwarden, exportKeePassXML, export1PasswordCSV}
+)
+
+func (f *fastmask) loadExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export masked emails for spreadsheets and password managers.",
+		Long: "Export masked emails as CSV, JSON, or in a format that can be imported into Bitwarden, KeePass " +
+			"or 1Password. Password manager entries use the masked email as the username and the url, or " +
+			"domain, as the website, so every login gets its masked address. Deleted masked emails are " +
+			"left out unless selected with --state.",
+		Example: "  fastmask export --format csv > masks.csv\n  fastmask export --format bitwarden -f bitwarden.json --state enabled",
+		Args:    cobra.NoArgs,
+		RunE:    f.runExport,
+	}
+
+	addFilterFlags(cmd)
+	cmd.Flags().String(flagFormat, exportCSV, "Export format, one of: "+strings.Join(exportFormats, ", ")+".")
+	cmd.Flags().StringP(flagFile, "f", "", "Write the export to a file instead of stdout.")
+
+	return cmd
+}
+
+func (f *fastmask) runExport(cmd *cobra.Command, _ []string) error {
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFormat, err)
+	}
+
+	if oneOf(exportFormats...)(format) != nil {
+		return fmt.Errorf("%w: '%s', expected one of: %s", errUnknownExportFormat, format, strings.Join(exportFormats, ", "))
+	}
+
+	filename, err := cmd.Flags().GetString(flagFile)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFile, err)
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	selected, err := selectExportMaskedEmails(cmd, maskedEmails)
+	if err != nil {
+		return err
+	}
+
+	// nolint:errcheck // the sort field is valid.
+	_ = fastmail.SortMaskedEmails(selected, fastmail.SortByCreatedAt)
+
+	if filename == "" {
+		return exportMaskedEmails(os.Stdout, format, selected)
+	}
+
+	var buf bytes.Buffer
+
+	if err := exportMaskedEmails(&buf, format, selected); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filename, buf.Bytes(), exportFilePermissions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d masked emails to %s.\n", len(selected), filename)
+
+	return nil
+}
+
+// selectExportMaskedEmails returns the masked emails selected by the filter flags, leaving out
+// deleted masked emails unless --state selects them, so exports do not bring back their logins.
+func selectExportMaskedEmails(cmd *cobra.Command, maskedEmails []fastmail.MaskedEmail) ([]fastmail.MaskedEmail, error) {
+	selected, err := selectMaskedEmails(cmd, maskedEmails, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cmd.Flags().Changed(flagState) {
+		selected = excludeState(selected, fastmail.StateDeleted)
+	}
+
+	return selected, nil
+}
+
+// exportMaskedEmails writes the masked emails to out in the given format.
+func exportMaskedEmails(out io.Writer, format string, maskedEmails []fastmail.MaskedEmail) error {
+	switch format {
+	case exportCSV:
+		return exportMaskedEmailsCSV(out, maskedEmails)
+	case exportJSON:
+		return writeJSON(out, maskedEmails)
+	case exportBitwarden:
+		return exportMaskedEmailsBitwarden(out, maskedEmails)
+	case exportKeePassXML:
+		return exportMaskedEmailsKeePass(out, maskedEmails)
+	case export1PasswordCSV:
+		return exportMaskedEmails1Password(out, maskedEmails)
+	}
+
+	return fmt.Errorf("%w: '%s'", errUnknownExportFormat, format)
+}
+
+func exportMaskedEmailsCSV(out io.Writer, maskedEmails []fastmail.MaskedEmail) error {
+	rows := [][]string{{"id", "email", "state", "domain", "url", "description", "created_by", "created_at", "last_message_at"}}
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+		rows = append(rows, []string{m.ID, m.Email, m.State, m.ForDomain, m.URL, m.Description, m.CreatedBy, m.CreatedAt, m.LastMessageAt})
+	}
+
+	return writeCSV(out, rows)
+}
+
+// exportMaskedEmails1Password writes the columns of the 1Password CSV import.
+func exportMaskedEmails1Password(out io.Writer, maskedEmails []fastmail.MaskedEmail) error {
+	rows := [][]string{{"title", "website", "username", "password", "notes"}}
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+		rows = append(rows, []string{exportTitle(m), exportURL(m), m.Email, "", m.Description})
+	}
+
+	return writeCSV(out, rows)
+}
+
+func writeCSV(out io.Writer, rows [][]string) error {
+	w := csv.NewWriter(out)
+
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return nil
+}
+
+// bitwardenExport is the unencrypted Bitwarden JSON export format.
+type bitwardenExport struct {
+	Encrypted bool            `json:"encrypted"`
+	Folders   []interface{}   `json:"folders"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int             `json:"type"`
+	Name     string          `json:"name"`
+	Notes    *string         `json:"notes"`
+	Favorite bool            `json:"favorite"`
+	Login    *bitwardenLogin `json:"login,omitempty"`
+}
+
+type bitwardenLogin struct {
+	URIs     []bitwardenURI `json:"uris"`
+	Username string         `json:"username"`
+	Password *string        `json:"password"`
+	TOTP     *string        `json:"totp"`
+}
+
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+func exportMaskedEmailsBitwarden(out io.Writer, maskedEmails []fastmail.MaskedEmail) error {
+	export := bitwardenExport{Folders: []interface{}{}, Items: make([]bitwardenItem, 0, len(maskedEmails))}
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+
+		item := bitwardenItem{
+			Type:  bitwardenTypeLogin,
+			Name:  exportTitle(m),
+			Login: &bitwardenLogin{URIs: []bitwardenURI{}, Username: m.Email},
+		}
+
+		if m.Description != "" {
+			description := m.Description
+			item.Notes = &description
+		}
+
+		if u := exportURL(m); u != "" {
+			item.Login.URIs = append(item.Login.URIs, bitwardenURI{URI: u})
+		}
+
+		export.Items = append(export.Items, item)
+	}
+
+	return writeJSON(out, export)
+}
+
+// keePassFile is the KeePass 2 XML export format.
+type keePassFile struct {
+	XMLName xml.Name     `xml:"KeePassFile"`
+	Root    keePassGroup `xml:"Root>Group"`
+}
+
+type keePassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+}
+
+type keePassEntry struct {
+	Strings []keePassString `xml:"String"`
+}
+
+type keePassString struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+func exportMaskedEmailsKeePass(out io.Writer, maskedEmails []fastmail.MaskedEmail) error {
+	file := keePassFile{Root: keePassGroup{Name: appName, Entries: make([]keePassEntry, 0, len(maskedEmails))}}
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+
+		file.Root.Entries = append(file.Root.Entries, keePassEntry{Strings: []keePassString{
+			{Key: "Title", Value: exportTitle(m)},
+			{Key: "UserName", Value: m.Email},
+			{Key: "Password", Value: ""},
+			{Key: "URL", Value: exportURL(m)},
+			{Key: "Notes", Value: m.Description},
+		}})
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return fmt.Errorf("failed to write xml: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "\t")
+
+	if err := encoder.Encode(file); err != nil {
+		return fmt.Errorf("failed to write xml: %w", err)
+	}
+
+	if _, err := io.WriteString(out, "\n"); err != nil {
+		return fmt.Errorf("failed to write xml: %w", err)
+	}
+
+	return nil
+}
+
+// exportTitle returns the name of the password manager entry for a masked email.
+func exportTitle(m *fastmail.MaskedEmail) string {
+	if hostname := m.Hostname(); hostname != "" {
+		return hostname
+	}
+
+	if u, err := url.Parse(m.URL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+
+	if m.Description != "" {
+		return m.Description
+	}
+
+	return m.Email
+}
+
+// exportURL returns the website of the password manager entry for a masked email.
+func exportURL(m *fastmail.MaskedEmail) string {
+	if m.URL != "" {
+		return m.URL
+	}
+
+	return m.ForDomain
+}
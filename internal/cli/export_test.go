@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Export_Masked_Emails(t *testing.T) {
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "one@fastmail.com", State: "enabled", ForDomain: "https://www.example.com", Description: "Shop, \"main\""},
+		{ID: "masked-2", Email: "two@fastmail.com", State: "disabled", URL: "https://forum.example.org/login"},
+	}
+
+	export := func(t *testing.T, format string) []byte {
+		t.Helper()
+
+		var buf bytes.Buffer
+
+		require.NoError(t, exportMaskedEmails(&buf, format, maskedEmails))
+
+		return buf.Bytes()
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		rows, err := csv.NewReader(bytes.NewReader(export(t, exportCSV))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		require.Equal(t, []string{"masked-1", "one@fastmail.com", "enabled", "https://www.example.com", "", "Shop, \"main\"", "", "", ""}, rows[1])
+	})
+
+	t.Run("1Password CSV", func(t *testing.T) {
+		rows, err := csv.NewReader(bytes.NewReader(export(t, export1PasswordCSV))).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{"title", "website", "username", "password", "notes"}, rows[0])
+		require.Equal(t, []string{"www.example.com", "https://www.example.com", "one@fastmail.com", "", "Shop, \"main\""}, rows[1])
+		require.Equal(t, []string{"forum.example.org", "https://forum.example.org/login", "two@fastmail.com", "", ""}, rows[2])
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var result []fastmail.MaskedEmail
+
+		require.NoError(t, json.Unmarshal(export(t, exportJSON), &result))
+		require.Equal(t, maskedEmails, result)
+	})
+
+	t.Run("Bitwarden", func(t *testing.T) {
+		var result bitwardenExport
+
+		require.NoError(t, json.Unmarshal(export(t, exportBitwarden), &result))
+		require.False(t, result.Encrypted)
+		require.Len(t, result.Items, 2)
+		require.Equal(t, bitwardenTypeLogin, result.Items[0].Type)
+		require.Equal(t, "one@fastmail.com", result.Items[0].Login.Username)
+		require.Equal(t, "https://www.example.com", result.Items[0].Login.URIs[0].URI)
+		require.Equal(t, "Shop, \"main\"", *result.Items[0].Notes)
+		require.Nil(t, result.Items[1].Notes)
+	})
+
+	t.Run("KeePass XML", func(t *testing.T) {
+		var result keePassFile
+
+		require.NoError(t, xml.Unmarshal(export(t, exportKeePassXML), &result))
+		require.Len(t, result.Root.Entries, 2)
+		require.Contains(t, result.Root.Entries[1].Strings, keePassString{Key: "UserName", Value: "two@fastmail.com"})
+		require.Contains(t, result.Root.Entries[1].Strings, keePassString{Key: "URL", Value: "https://forum.example.org/login"})
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		require.ErrorIs(t, exportMaskedEmails(&bytes.Buffer{}, "pdf", maskedEmails), errUnknownExportFormat)
+	})
+}
+
+func Test_Select_Export_Masked_Emails(t *testing.T) {
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", State: fastmail.StateEnabled},
+		{ID: "masked-2", State: fastmail.StateDeleted},
+		{ID: "masked-3", State: fastmail.StateDisabled},
+	}
+
+	cmd := &cobra.Command{}
+	addFilterFlags(cmd)
+
+	selected, err := selectExportMaskedEmails(cmd, maskedEmails)
+	require.NoError(t, err)
+	require.Equal(t, []string{"masked-1", "masked-3"}, maskedEmailIDs(selected), "deleted masked emails are left out by default")
+
+	require.NoError(t, cmd.ParseFlags([]string{"--state", "deleted"}))
+
+	selected, err = selectExportMaskedEmails(cmd, maskedEmails)
+	require.NoError(t, err)
+	require.Equal(t, []string{"masked-2"}, maskedEmailIDs(selected))
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagState       = "state"
+	flagDomain      = "domain"
+	flagCreatedBy   = "created-by"
+	flagSince       = "since"
+	flagUntil       = "until"
+	flagInactiveFor = "inactive-for"
+	flagSort        = "sort"
+
+	hoursPerDay = 24
+	daysPerWeek = 7
+)
+
+var (
+	errInvalidDuration = errors.New("invalid duration, use eg. 12h, 30d or 2w")
+	errInvalidTime     = errors.New("invalid time, use eg. 2022-04-20, 2022-04-20T12:00:00Z or 30d for 30 days ago")
+	errInvalidState    = errors.New("invalid state")
+
+	maskedEmailStates = []string{fastmail.StateEnabled, fastmail.StateDisabled, fastmail.StatePending, fastmail.StateDeleted}
+)
+
+// addFilterFlags adds the flags used to select masked emails to cmd.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice(flagState, nil, "Only masked emails in the given states: "+strings.Join(maskedEmailStates, ", ")+".")
+	cmd.Flags().String(flagDomain, "", "Only masked emails for domains matching a glob, eg. '*.example.com', or a regex in slashes, eg. '/example\\.(com|org)/'.")
+	cmd.Flags().StringSlice(flagCreatedBy, nil, "Only masked emails created by the given apps, eg. 'fastmask' or '1Password'.")
+	cmd.Flags().String(flagSince, "", "Only masked emails created at or after a date, time or age, eg. 2022-04-20 or 30d.")
+	cmd.Flags().String(flagUntil, "", "Only masked emails created before a date, time or age, eg. 2022-04-20 or 30d.")
+	cmd.Flags().String(flagInactiveFor, "", "Only masked emails without messages for a duration, eg. 180d.")
+}
+
+// filterFromFlags returns the masked email filter described by the flags added by addFilterFlags.
+func filterFromFlags(cmd *cobra.Command, now time.Time) (*fastmail.MaskedEmailFilter, error) {
+	filter := &fastmail.MaskedEmailFilter{Now: now}
+
+	var err error
+
+	if filter.States, err = cmd.Flags().GetStringSlice(flagState); err != nil {
+		return nil, fmt.Errorf("failed to get flag %s: %w", flagState, err)
+	}
+
+	for _, state := range filter.States {
+		if oneOf(maskedEmailStates...)(strings.ToLower(state)) != nil {
+			return nil, fmt.Errorf("%w: '%s', expected one of: %s", errInvalidState, state, strings.Join(maskedEmailStates, ", "))
+		}
+	}
+
+	if filter.CreatedBy, err = cmd.Flags().GetStringSlice(flagCreatedBy); err != nil {
+		return nil, fmt.Errorf("failed to get flag %s: %w", flagCreatedBy, err)
+	}
+
+	domain, err := cmd.Flags().GetString(flagDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag %s: %w", flagDomain, err)
+	}
+
+	if err := setDomainFilter(filter, domain); err != nil {
+		return nil, err
+	}
+
+	if filter.Since, err = timeFlag(cmd, flagSince, now); err != nil {
+		return nil, err
+	}
+
+	if filter.Until, err = timeFlag(cmd, flagUntil, now); err != nil {
+		return nil, err
+	}
+
+	inactiveFor, err := cmd.Flags().GetString(flagInactiveFor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag %s: %w", flagInactiveFor, err)
+	}
+
+	if inactiveFor != "" {
+		if filter.InactiveFor, err = parseDuration(inactiveFor); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", flagInactiveFor, err)
+		}
+	}
+
+	return filter, nil
+}
+
+// setDomainFilter sets the domain of filter to a glob, or a regex in slashes, eg. '/example\.(com|org)/'.
+func setDomainFilter(filter *fastmail.MaskedEmailFilter, domain string) error {
+	if len(domain) > 2 && strings.HasPrefix(domain, "/") && strings.HasSuffix(domain, "/") {
+		re, err := regexp.Compile("(?i)" + domain[1:len(domain)-1])
+		if err != nil {
+			return fmt.Errorf("invalid domain regex: %w", err)
+		}
+
+		filter.DomainRegexp = re
+
+		return nil
+	}
+
+	filter.Domain = domain
+
+	return nil
+}
+
+func timeFlag(cmd *cobra.Command, name string, now time.Time) (time.Time, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get flag %s: %w", name, err)
+	}
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := parseTime(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// parseDuration parses a Go duration, with support for days and weeks, eg. '180d' or '2w'.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	for suffix, unit := range map[string]time.Duration{
+		"d": hoursPerDay * time.Hour,
+		"w": daysPerWeek * hoursPerDay * time.Hour,
+	} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) && n >= 0 {
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%w: '%s'", errInvalidDuration, s)
+	}
+
+	return d, nil
+}
+
+// parseTime parses a date, RFC3339 time, or a duration relative to now, eg. '30d' for 30 days ago.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	if d, err := parseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: '%s'", errInvalidTime, s)
+}
+
+// filterFlagsChanged returns true if any of the flags added by addFilterFlags were set.
+func filterFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range []string{flagState, flagDomain, flagCreatedBy, flagSince, flagUntil, flagInactiveFor} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectMaskedEmails returns the masked emails with the given IDs or email addresses, or all
+// masked emails when none are given, narrowed down by the filter flags.
+func selectMaskedEmails(cmd *cobra.Command, maskedEmails []fastmail.MaskedEmail, ids []string) ([]fastmail.MaskedEmail, error) {
+	filter, err := filterFromFlags(cmd, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return filter.Filter(maskedEmails), nil
+	}
+
+	selected := make([]fastmail.MaskedEmail, 0, len(ids))
+
+	for _, id := range ids {
+		found := false
+
+		for i := range maskedEmails {
+			if maskedEmails[i].ID == id || strings.EqualFold(maskedEmails[i].Email, id) {
+				selected = append(selected, maskedEmails[i])
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w with ID or email '%s'", errMaskedEmailNotFound, id)
+		}
+	}
+
+	return filter.Filter(selected), nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Filter_Flags(t *testing.T) {
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Parse Duration", func(t *testing.T) {
+		for input, expected := range map[string]time.Duration{
+			"180d": 180 * 24 * time.Hour,
+			"2w":   14 * 24 * time.Hour,
+			"12h":  12 * time.Hour,
+		} {
+			d, err := parseDuration(input)
+			require.NoError(t, err, input)
+			require.Equal(t, expected, d, input)
+		}
+
+		for _, input := range []string{"", "5", "-1d", "abc"} {
+			_, err := parseDuration(input)
+			require.ErrorIs(t, err, errInvalidDuration, input)
+		}
+	})
+
+	t.Run("Parse Time", func(t *testing.T) {
+		tm, err := parseTime("2022-04-20T12:00:00Z", now)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2022, 4, 20, 12, 0, 0, 0, time.UTC), tm)
+
+		tm, err = parseTime("30d", now)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(-30*24*time.Hour), tm)
+
+		_, err = parseTime("yesterday", now)
+		require.ErrorIs(t, err, errInvalidTime)
+	})
+
+	t.Run("Filter From Flags", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		addFilterFlags(cmd)
+		require.NoError(t, cmd.ParseFlags([]string{"--state", "enabled,disabled", "--domain", "/example\\.com$/", "--inactive-for", "180d"}))
+
+		filter, err := filterFromFlags(cmd, now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"enabled", "disabled"}, filter.States)
+		require.NotNil(t, filter.DomainRegexp)
+		require.True(t, filter.DomainRegexp.MatchString("https://WWW.EXAMPLE.COM"))
+		require.Equal(t, 180*24*time.Hour, filter.InactiveFor)
+
+		cmd = &cobra.Command{}
+		addFilterFlags(cmd)
+		require.NoError(t, cmd.ParseFlags([]string{"--state", "active"}))
+
+		_, err = filterFromFlags(cmd, now)
+		require.ErrorIs(t, err, errInvalidState)
+	})
+}
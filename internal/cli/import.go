@@ -0,0 +1,405 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagFrom   = "from"
+	flagReport = "report"
+
+	importBitwardenJSON   = "bitwarden-json"
+	import1PasswordCSV    = "1password-csv"
+	importFirefoxLoginCSV = "firefox-logins-csv"
+	importChromeCSV       = "chrome-csv"
+
+	defaultImportReport = "fastmask-import.csv"
+)
+
+var (
+	errUnknownImportFormat = errors.New("unknown import format")
+	errInvalidImportFile   = errors.New("invalid import file")
+
+	importFormats = []string{importBitwardenJSON, import1PasswordCSV, importFirefoxLoginCSV, importChromeCSV}
+
+	// importCSVColumns are the url and title columns of each CSV format, the first column present is used.
+	importCSVColumns = map[string]struct{ url, title []string }{
+		import1PasswordCSV:    {url: []string{"url", "website", "urls", "login_uri"}, title: []string{"title", "name"}},
+		importFirefoxLoginCSV: {url: []string{"url"}},
+		importChromeCSV:       {url: []string{"url", "origin"}, title: []string{"name"}},
+	}
+)
+
+// importedLogin is a site login read from a password manager export.
+type importedLogin struct {
+	Site  string
+	Title string
+}
+
+// importEntry is a site to create a masked email for, with the result.
+type importEntry struct {
+	// Site is the first url seen for the domain.
+	Site   string
+	Domain string
+	Title  string
+	Status string
+	// MaskedEmail is the existing or created masked email.
+	MaskedEmail fastmail.MaskedEmail
+	Error       string
+}
+
+func (f *fastmask) loadImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import --from <format> <file>",
+		Short: "Create masked emails for the logins in a password manager export.",
+		Long: "Read a password manager export, create a masked email for every site that does not have one " +
+			"yet, and write a report mapping each site to its masked email, for updating the email address " +
+			"of each account.\n\nSites are matched by domain, ignoring a leading 'www.', and only http and " +
+			"https sites are imported.",
+		Example: "  fastmask import --from bitwarden-json bitwarden_export.json\n" +
+			"  fastmask import --from chrome-csv 'Chrome Passwords.csv' --report chrome-masks.csv --dry-run",
+		Args: cobra.ExactArgs(1),
+		RunE: f.runImport,
+	}
+
+	cmd.Flags().String(flagFrom, "", "Export format, one of: "+strings.Join(importFormats, ", ")+".")
+	cmd.Flags().String(flagReport, defaultImportReport, "CSV report mapping each site to its masked email.")
+	cmd.Flags().Bool(flagDryRun, false, "Show the sites masked emails would be created for without creating them.")
+	// nolint:errcheck // flag exists.
+	cmd.MarkFlagRequired(flagFrom)
+
+	return cmd
+}
+
+func (f *fastmask) runImport(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString(flagFrom)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFrom, err)
+	}
+
+	report, err := cmd.Flags().GetString(flagReport)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagReport, err)
+	}
+
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagDryRun, err)
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+
+	defer file.Close()
+
+	logins, err := parseLoginExport(format, file)
+	if err != nil {
+		return err
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	entries := planImport(logins, maskedEmails)
+
+	var pending []*importEntry
+
+	for i := range entries {
+		if entries[i].Status == "" {
+			pending = append(pending, &entries[i])
+		}
+	}
+
+	fmt.Printf("Found %d sites, %d already have a masked email.\n", len(entries), len(entries)-len(pending))
+
+	if len(pending) == 0 {
+		if err := writeImportReport(report, entries); err != nil {
+			return err
+		}
+
+		fmt.Printf("Report written to %s.\n", report)
+
+		return nil
+	}
+
+	for _, entry := range pending {
+		fmt.Printf("  %s\n", entry.Domain)
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run, %d masked emails would be created.\n", len(pending))
+
+		return nil
+	}
+
+	ok, err := confirm(cmd, fmt.Sprintf("\nCreate %d masked emails", len(pending)))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	createErr := createImportedMaskedEmails(cmd.Context(), client, pending)
+
+	if err := writeImportReport(report, entries); err != nil {
+		return err
+	}
+
+	fmt.Printf("Report written to %s.\n", report)
+
+	return createErr
+}
+
+// parseLoginExport reads the logins of a password manager export in the given format.
+func parseLoginExport(format string, r io.Reader) ([]importedLogin, error) {
+	if format == importBitwardenJSON {
+		return parseBitwardenExport(r)
+	}
+
+	columns, ok := importCSVColumns[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s', expected one of: %s", errUnknownImportFormat, format, strings.Join(importFormats, ", "))
+	}
+
+	rows, err := readCSVRecords(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var logins []importedLogin
+
+	for _, row := range rows {
+		login := importedLogin{Site: firstColumn(row, columns.url), Title: firstColumn(row, columns.title)}
+
+		// 1Password may list several urls in one column.
+		for _, site := range strings.Fields(strings.ReplaceAll(login.Site, ",", " ")) {
+			logins = append(logins, importedLogin{Site: site, Title: login.Title})
+		}
+	}
+
+	return logins, nil
+}
+
+func parseBitwardenExport(r io.Reader) ([]importedLogin, error) {
+	var export bitwardenExport
+
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidImportFile, err.Error())
+	}
+
+	if export.Encrypted {
+		return nil, fmt.Errorf("%w: encrypted Bitwarden exports are not supported, export as unencrypted json", errInvalidImportFile)
+	}
+
+	var logins []importedLogin
+
+	for _, item := range export.Items {
+		if item.Type != bitwardenTypeLogin || item.Login == nil {
+			continue
+		}
+
+		for _, uri := range item.Login.URIs {
+			logins = append(logins, importedLogin{Site: uri.URI, Title: item.Name})
+		}
+	}
+
+	return logins, nil
+}
+
+// readCSVRecords reads a CSV file with a header row, returning each row keyed by lowercase column name.
+func readCSVRecords(r io.Reader) ([]map[string]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	// Some exports start with a byte order mark, which would break quoted headers.
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidImportFile, err.Error())
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func firstColumn(row map[string]string, columns []string) string {
+	for _, column := range columns {
+		if value := row[column]; value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// normalizeSite returns the domain of a site url, without a leading 'www.', and its origin. ok is
+// false for urls that are not http or https websites, eg. 'android://' apps or local addresses.
+func normalizeSite(site string) (domain, origin string, ok bool) {
+	site = strings.TrimSpace(site)
+	if site == "" {
+		return "", "", false
+	}
+
+	if !strings.Contains(site, "://") {
+		site = "https://" + site
+	}
+
+	u, err := url.Parse(site)
+	if err != nil {
+		return "", "", false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if (scheme != "http" && scheme != "https") || !strings.Contains(host, ".") || net.ParseIP(host) != nil {
+		return "", "", false
+	}
+
+	return strings.TrimPrefix(host, "www."), scheme + "://" + host, true
+}
+
+// planImport returns an entry for each unique domain of the logins in sorted order, with the
+// existing masked email and status set for domains that already have one.
+func planImport(logins []importedLogin, maskedEmails []fastmail.MaskedEmail) []importEntry {
+	existing := map[string]fastmail.MaskedEmail{}
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+		if m.State == fastmail.StateDeleted {
+			continue
+		}
+
+		for _, site := range []string{m.ForDomain, m.URL} {
+			if domain, _, ok := normalizeSite(site); ok {
+				if _, found := existing[domain]; !found {
+					existing[domain] = *m
+				}
+			}
+		}
+	}
+
+	byDomain := map[string]*importEntry{}
+
+	for _, login := range logins {
+		domain, origin, ok := normalizeSite(login.Site)
+		if !ok || byDomain[domain] != nil {
+			continue
+		}
+
+		entry := &importEntry{Site: origin, Domain: domain, Title: login.Title}
+
+		if m, found := existing[domain]; found {
+			entry.Status, entry.MaskedEmail = createStatusExisting, m
+		}
+
+		byDomain[domain] = entry
+	}
+
+	entries := make([]importEntry, 0, len(byDomain))
+	for _, entry := range byDomain {
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Domain < entries[j].Domain })
+
+	return entries
+}
+
+// createImportedMaskedEmails creates a masked email for each entry, setting the status of each entry.
+func createImportedMaskedEmails(ctx context.Context, client *fastmail.Client, entries []*importEntry) error {
+	maskedEmails := make([]*fastmail.MaskedEmail, len(entries))
+
+	for i, entry := range entries {
+		description := entry.Title
+		if description == "" {
+			description = entry.Domain
+		}
+
+		maskedEmails[i] = &fastmail.MaskedEmail{ForDomain: entry.Site, Description: description, State: fastmail.StateEnabled}
+	}
+
+	results, err := createMaskedEmailsInBatches(ctx, client, maskedEmails)
+
+	for i, entry := range entries {
+		entry.Status, entry.MaskedEmail, entry.Error = results[i].status(), results[i].MaskedEmail, results[i].Error
+	}
+
+	return err
+}
+
+// writeImportReport writes a CSV report mapping each site to its masked email.
+func writeImportReport(filename string, entries []importEntry) error {
+	rows := [][]string{{"site", "domain", "email", "id", "status", "error"}}
+
+	for i := range entries {
+		e := &entries[i]
+		rows = append(rows, []string{e.Site, e.Domain, e.MaskedEmail.Email, e.MaskedEmail.ID, e.Status, strings.TrimSpace(e.Error)})
+	}
+
+	var buf strings.Builder
+
+	if err := writeCSV(&buf, rows); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filename, []byte(buf.String()), exportFilePermissions)
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Parse_Login_Export(t *testing.T) {
+	for _, tc := range []struct {
+		format  string
+		content string
+		want    []importedLogin
+	}{
+		{
+			format: importBitwardenJSON,
+			content: `{"encrypted": false, "items": [
+				{"type": 1, "name": "Shop", "login": {"uris": [{"uri": "https://shop.example.com/login"}, {"uri": "https://example.com"}]}},
+				{"type": 2, "name": "Secure note"}
+			]}`,
+			want: []importedLogin{{Site: "https://shop.example.com/login", Title: "Shop"}, {Site: "https://example.com", Title: "Shop"}},
+		},
+		{
+			format:  import1PasswordCSV,
+			content: "\ufeffTitle,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes\nForum,\"https://forum.example.org, https://example.org\",me,pw,,false,false,,\n",
+			want:    []importedLogin{{Site: "https://forum.example.org", Title: "Forum"}, {Site: "https://example.org", Title: "Forum"}},
+		},
+		{
+			format:  importFirefoxLoginCSV,
+			content: "\ufeff\"url\",\"username\",\"password\",\"httpRealm\",\"formActionOrigin\",\"guid\",\"timeCreated\",\"timeLastUsed\",\"timePasswordChanged\"\n\"https://www.example.net\",\"me\",\"pw\",,\"https://www.example.net\",\"{1}\",\"1\",\"1\",\"1\"\n",
+			want:    []importedLogin{{Site: "https://www.example.net"}},
+		},
+		{
+			format:  importChromeCSV,
+			content: "name,url,username,password,note\nexample.com,https://example.com/,me,pw,\n",
+			want:    []importedLogin{{Site: "https://example.com/", Title: "example.com"}},
+		},
+	} {
+		logins, err := parseLoginExport(tc.format, strings.NewReader(tc.content))
+		require.NoError(t, err, tc.format)
+		require.Equal(t, tc.want, logins, tc.format)
+	}
+
+	_, err := parseLoginExport(importBitwardenJSON, strings.NewReader(`{"encrypted": true}`))
+	require.ErrorIs(t, err, errInvalidImportFile)
+
+	_, err = parseLoginExport("lastpass", strings.NewReader(""))
+	require.ErrorIs(t, err, errUnknownImportFormat)
+}
+
+func Test_Normalize_Site(t *testing.T) {
+	for site, want := range map[string][2]string{
+		"https://www.Example.com/login?next=/": {"example.com", "https://www.example.com"},
+		"example.org":                          {"example.org", "https://example.org"},
+		"http://shop.example.net:8080/":        {"shop.example.net", "http://shop.example.net"},
+	} {
+		domain, origin, ok := normalizeSite(site)
+		require.True(t, ok, site)
+		require.Equal(t, want, [2]string{domain, origin}, site)
+	}
+
+	for _, site := range []string{"", "android://abc@com.example.app/", "http://localhost:3000", "https://192.168.1.1"} {
+		_, _, ok := normalizeSite(site)
+		require.False(t, ok, site)
+	}
+}
+
+func Test_Plan_Import(t *testing.T) {
+	logins := []importedLogin{
+		{Site: "https://www.example.com/login", Title: "Example"},
+		{Site: "https://example.com/account"},
+		{Site: "https://forum.example.org"},
+		{Site: "https://deleted.example.net"},
+		{Site: "android://app"},
+	}
+
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "one@fastmail.com", ForDomain: "https://forum.example.org", State: "enabled"},
+		{ID: "masked-2", Email: "two@fastmail.com", ForDomain: "https://deleted.example.net", State: "deleted"},
+	}
+
+	entries := planImport(logins, maskedEmails)
+	require.Len(t, entries, 3)
+
+	require.Equal(t, importEntry{Site: "https://deleted.example.net", Domain: "deleted.example.net"}, entries[0])
+	require.Equal(t, importEntry{Site: "https://www.example.com", Domain: "example.com", Title: "Example"}, entries[1])
+	require.Equal(t, "forum.example.org", entries[2].Domain)
+	require.Equal(t, createStatusExisting, entries[2].Status)
+	require.Equal(t, "masked-1", entries[2].MaskedEmail.ID)
+
+	report := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, writeImportReport(report, entries))
+
+	b, err := os.ReadFile(report)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "https://forum.example.org,forum.example.org,one@fastmail.com,masked-1,existing,\n")
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagAllow = "allow"
+
+	defaultLeaksLimit = 200
+)
+
+// leakReport is a masked email receiving mail from senders unrelated to its domain.
+type leakReport struct {
+	ID     string `json:"id"`
+	Email  string `json:"email"`
+	Domain string `json:"domain"`
+	// Messages is the number of messages checked, Total the number delivered to the mask.
+	Messages          int          `json:"messages"`
+	Total             int          `json:"total"`
+	UnrelatedMessages int          `json:"unrelatedMessages"`
+	Senders           []leakSender `json:"senders"`
+}
+
+// leakSender is an unrelated sender domain, with the number of messages it sent to the masked
+// email and when the first arrived, not only those within the messages checked.
+type leakSender struct {
+	Domain    string `json:"domain"`
+	Messages  int    `json:"messages"`
+	FirstSeen string `json:"firstSeen"`
+}
+
+func (f *fastmask) loadLeaksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "leaks [id or email...]",
+		Short: "Find masked emails receiving mail from unrelated senders.",
+		Long: "Check the mail delivered to each masked email and report those receiving mail from senders whose " +
+			"domain does not match the masked email's domain, a sign the address was sold or leaked. Senders " +
+			"match when they share the registrable domain, eg. 'mail.example.com' matches 'www.example.com'. " +
+			"Mail is matched by the To, Cc and Bcc headers and the address it was delivered to. Masked emails " +
+			"without a domain are skipped, and unrelated senders are found in the newest --limit messages of " +
+			"each, with their message count and first message looked up over all mail.\n\n" +
+			"The API token needs access to email, as well as masked emails.",
+		Example: "  fastmask leaks -o table\n  fastmask leaks --state enabled --allow mailchimp.com,sendgrid.net",
+		RunE:    f.runLeaks,
+	}
+
+	addFilterFlags(cmd)
+	cmd.Flags().Int(flagLimit, defaultLeaksLimit, "Maximum number of messages checked per masked email, 0 for the server's limit.")
+	cmd.Flags().StringSlice(flagAllow, nil, "Sender domains related to every masked email, eg. mailing list providers.")
+
+	return cmd
+}
+
+func (f *fastmask) runLeaks(cmd *cobra.Command, args []string) error {
+	limit, err := cmd.Flags().GetInt(flagLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagLimit, err)
+	}
+
+	allow, err := cmd.Flags().GetStringSlice(flagAllow)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagAllow, err)
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	reports, err := findLeaks(cmd, client, args, limit, allow)
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return err
+	}
+
+	if len(reports) == 0 {
+		fmt.Fprintln(os.Stderr, "No leaks found.")
+	}
+
+	return f.writeOutput(reports)
+}
+
+func findLeaks(cmd *cobra.Command, client *fastmail.Client, ids []string, limit int, allow []string) ([]leakReport, error) {
+	ctx := cmd.Context()
+
+	maskedEmails, err := client.GetMaskedEmails(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	if maskedEmails, err = selectMaskedEmails(cmd, maskedEmails, ids); err != nil {
+		return nil, err
+	}
+
+	return checkLeaks(ctx, client, withDomain(maskedEmails), limit, allow)
+}
+
+// withDomain returns the masked emails that have a domain.
+func withDomain(maskedEmails []fastmail.MaskedEmail) []fastmail.MaskedEmail {
+	result := make([]fastmail.MaskedEmail, 0, len(maskedEmails))
+
+	for i := range maskedEmails {
+		if maskedEmails[i].Hostname() != "" {
+			result = append(result, maskedEmails[i])
+		}
+	}
+
+	return result
+}
+
+// checkLeaks queries the mail delivered to the masked emails, returning a report for each receiving
+// mail from unrelated senders.
+func checkLeaks(ctx context.Context, client *fastmail.Client, maskedEmails []fastmail.MaskedEmail, limit int, allow []string) ([]leakReport, error) {
+	reports := []leakReport{}
+
+	if len(maskedEmails) == 0 {
+		return reports, nil
+	}
+
+	accountID, err := client.MailAccountID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mail account: %w", err)
+	}
+
+	results, err := queryMaskedEmailMail(ctx, client, accountID, maskedEmails, func(m *fastmail.MaskedEmail) fastmail.EmailQuery {
+		return fastmail.EmailQuery{Recipient: m.Email, Limit: limit}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range maskedEmails {
+		if report := leakReportFor(&maskedEmails[i], &results[i], allow); report != nil {
+			reports = append(reports, *report)
+		}
+	}
+
+	if err := lookupLeakSenders(ctx, client, accountID, reports); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// lookupLeakSenders sets the message count and first message of each sender of the reports over
+// all mail to the masked email, as the messages checked may only be the newest.
+func lookupLeakSenders(ctx context.Context, client *fastmail.Client, accountID string, reports []leakReport) error {
+	var (
+		queries []fastmail.EmailQuery
+		senders []*leakSender
+	)
+
+	for i := range reports {
+		for j := range reports[i].Senders {
+			sender := &reports[i].Senders[j]
+			senders = append(senders, sender)
+			queries = append(queries, fastmail.EmailQuery{
+				Filter:    fastmail.EmailFilter{From: "@" + sender.Domain},
+				Recipient: reports[i].Email,
+				Ascending: true,
+				Limit:     1,
+			})
+		}
+	}
+
+	results, err := queryEmailsInBatches(ctx, client, accountID, queries)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		senders[i].update(&results[i])
+	}
+
+	for i := range reports {
+		sortLeakSenders(reports[i].Senders)
+	}
+
+	return nil
+}
+
+// update sets the message count and first message from the result of the sender's oldest message.
+func (s *leakSender) update(result *fastmail.EmailQueryResult) {
+	if result.Total > s.Messages {
+		s.Messages = result.Total
+	}
+
+	if len(result.Emails) > 0 && result.Emails[0].ReceivedAt < s.FirstSeen {
+		s.FirstSeen = result.Emails[0].ReceivedAt
+	}
+}
+
+// leakReportFor returns the report of the messages delivered to m from unrelated senders, or nil
+// if there are none.
+func leakReportFor(m *fastmail.MaskedEmail, result *fastmail.EmailQueryResult, allow []string) *leakReport {
+	related := map[string]bool{registrableDomain(m.Hostname()): true}
+	for _, domain := range allow {
+		related[registrableDomain(strings.TrimSpace(domain))] = true
+	}
+
+	report := &leakReport{ID: m.ID, Email: m.Email, Domain: m.ForDomain, Messages: len(result.Emails), Total: result.Total}
+	senders := map[string]*leakSender{}
+
+	for i := range result.Emails {
+		email := &result.Emails[i]
+
+		domain := addressDomain(email.Sender())
+		if domain == "" || related[registrableDomain(domain)] {
+			continue
+		}
+
+		sender, ok := senders[domain]
+		if !ok {
+			sender = &leakSender{Domain: domain, FirstSeen: email.ReceivedAt}
+			senders[domain] = sender
+		}
+
+		sender.Messages++
+		if email.ReceivedAt < sender.FirstSeen {
+			sender.FirstSeen = email.ReceivedAt
+		}
+
+		report.UnrelatedMessages++
+	}
+
+	if len(senders) == 0 {
+		return nil
+	}
+
+	for _, sender := range senders {
+		report.Senders = append(report.Senders, *sender)
+	}
+
+	sortLeakSenders(report.Senders)
+
+	return report
+}
+
+// sortLeakSenders sorts senders by most messages first.
+func sortLeakSenders(senders []leakSender) {
+	sort.Slice(senders, func(i, j int) bool {
+		a, b := senders[i], senders[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+
+		return a.Domain < b.Domain
+	})
+}
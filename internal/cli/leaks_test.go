@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Registrable_Domain(t *testing.T) {
+	for hostname, want := range map[string]string{
+		"www.example.com":    "example.com",
+		"Mail.Example.co.uk": "example.co.uk",
+		"example.com.":       "example.com",
+		"localhost":          "localhost",
+	} {
+		require.Equal(t, want, registrableDomain(hostname), hostname)
+	}
+
+	require.Equal(t, "shop.example.com", addressDomain("news@Shop.Example.com"))
+	require.Empty(t, addressDomain("undisclosed-recipients"))
+}
+
+func Test_Leak_Report(t *testing.T) {
+	m := &fastmail.MaskedEmail{ID: "masked-1", Email: "one@fastmail.com", ForDomain: "https://www.shop.com"}
+
+	sent := func(from, receivedAt string) fastmail.Email {
+		return fastmail.Email{From: []fastmail.EmailAddress{{Email: from}}, ReceivedAt: receivedAt}
+	}
+
+	result := &fastmail.EmailQueryResult{
+		Emails: []fastmail.Email{
+			sent("offers@spam.example", "2022-06-03T00:00:00Z"),
+			sent("orders@mail.shop.com", "2022-06-02T00:00:00Z"),
+			sent("offers@spam.example", "2022-06-01T00:00:00Z"),
+			sent("list@mailchimp.com", "2022-05-20T00:00:00Z"),
+			sent("deals@other.example", "2022-05-10T00:00:00Z"),
+			{ReceivedAt: "2022-05-01T00:00:00Z"},
+		},
+		Total: 10,
+	}
+
+	require.Equal(t, &leakReport{
+		ID:                "masked-1",
+		Email:             "one@fastmail.com",
+		Domain:            "https://www.shop.com",
+		Messages:          6,
+		Total:             10,
+		UnrelatedMessages: 3,
+		Senders: []leakSender{
+			{Domain: "spam.example", Messages: 2, FirstSeen: "2022-06-01T00:00:00Z"},
+			{Domain: "other.example", Messages: 1, FirstSeen: "2022-05-10T00:00:00Z"},
+		},
+	}, leakReportFor(m, result, []string{"mailchimp.com"}))
+
+	// Mail only from related senders is not a leak.
+	result.Emails = result.Emails[1:2]
+	require.Nil(t, leakReportFor(m, result, nil))
+}
+
+func Test_Leak_Sender_Update(t *testing.T) {
+	sender := &leakSender{Domain: "spam.example", Messages: 2, FirstSeen: "2022-06-01T00:00:00Z"}
+
+	// The oldest message from the sender is before the messages checked.
+	sender.update(&fastmail.EmailQueryResult{
+		Emails: []fastmail.Email{{ReceivedAt: "2021-01-15T00:00:00Z"}},
+		Total:  7,
+	})
+	require.Equal(t, &leakSender{Domain: "spam.example", Messages: 7, FirstSeen: "2021-01-15T00:00:00Z"}, sender)
+
+	// An empty result leaves the sender as it was.
+	sender.update(&fastmail.EmailQueryResult{})
+	require.Equal(t, &leakSender{Domain: "spam.example", Messages: 7, FirstSeen: "2021-01-15T00:00:00Z"}, sender)
+}
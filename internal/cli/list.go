@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func (f *fastmask) loadListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List masked emails.",
+		Long:    "List masked emails, optionally filtered and sorted.",
+		Example: "  fastmask list --state enabled --domain '*.example.com'\n  fastmask list --created-by 1Password --inactive-for 180d --sort -last-message",
+		Args:    cobra.NoArgs,
+		RunE:    f.runList,
+	}
+
+	addFilterFlags(cmd)
+	addCacheFlags(cmd)
+	cmd.Flags().String(flagSort, "", "Sort by one of: "+strings.Join(fastmail.SortFields(), ", ")+", prefix with '-' for descending order.")
+
+	return cmd
+}
+
+func (f *fastmask) runList(cmd *cobra.Command, _ []string) error {
+	filter, err := filterFromFlags(cmd, time.Now())
+	if err != nil {
+		return err
+	}
+
+	sortBy, err := cmd.Flags().GetString(flagSort)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagSort, err)
+	}
+
+	maskedEmails, err := f.getCachedMaskedEmails(cmd)
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	maskedEmails = filter.Filter(maskedEmails)
+
+	if sortBy != "" {
+		if err := fastmail.SortMaskedEmails(maskedEmails, sortBy); err != nil {
+			return err
+		}
+	}
+
+	return f.writeOutput(maskedEmails)
+}
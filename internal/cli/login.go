@@ -15,7 +15,7 @@ func (f *fastmask) loadLoginCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "login",
 		Short: "Login with Fastmail",
-		Long:  "Login with Fastmail and store auth token in config file.",
+		Long:  "Login with Fastmail and store auth token in the credential store.",
 		RunE:  f.runLogin,
 	}
 
@@ -31,6 +31,10 @@ func reauthNeeded(err error) bool {
 		return false
 	}
 
+	if errors.Is(err, fastmail.ErrUnauthorized) {
+		return true
+	}
+
 	var apiError fastmail.APIError
 
 	if errors.As(err, &apiError) {
@@ -70,14 +74,18 @@ func (f *fastmask) runLogin(cmd *cobra.Command, args []string) error {
 		return errAccountIDNotFound
 	}
 
-	f.config.setAccountID(accountID)
-	f.config.setAccessToken(resp.GetAccessToken())
+	f.config.v.Set(profileKey(f.config.profile, configKeyUsername), username)
 
-	fmt.Println("🟢 Login success. Stored access token in config file.")
+	creds := &credentials{
+		AccountID:   accountID,
+		AccessToken: resp.GetAccessToken(),
+	}
 
-	if err := f.config.Save(); err != nil {
+	if err := f.config.saveCredentials(creds); err != nil {
 		return err
 	}
 
+	fmt.Printf("🟢 Login success. Stored access token for profile '%s' in credential store.\n", f.config.profile)
+
 	return nil
 }
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func (f *fastmask) loadLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Remove stored credentials.",
+		Long:  "Remove the stored credentials of the active profile from the credential store.",
+		Args:  cobra.NoArgs,
+		RunE:  f.runLogout,
+	}
+}
+
+func (f *fastmask) runLogout(_ *cobra.Command, _ []string) error {
+	store, err := f.config.credentialStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(f.config.profile); err != nil {
+		return fmt.Errorf("failed to remove credentials from %s store: %w", store.Name(), err)
+	}
+
+	if f.config.v.IsSet(configKeyAccessToken) || f.config.v.IsSet(configKeyAccountID) {
+		f.config.unset(configKeyAccountID)
+		f.config.unset(configKeyAccessToken)
+
+		if err := f.config.Save(); err != nil {
+			return err
+		}
+	}
+
+	if err := f.clearCache(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to remove cached masked emails: %s\n", err)
+	}
+
+	fmt.Printf("👋 Logged out of profile '%s'.\n", f.config.profile)
+
+	if f.config.envCreds != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Credentials are still set by %s and %s.\n", envName(configKeyAccountID), envName(configKeyAccessToken))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+// mailQueryBatchSize is the number of masked emails queried per request, each is two method calls
+// and Fastmail allows 50 calls in a request.
+const mailQueryBatchSize = 25
+
+// queryMaskedEmailMail returns the result of the query built for each masked email, in the same
+// order, sending the queries in batches.
+func queryMaskedEmailMail(
+	ctx context.Context,
+	client *fastmail.Client,
+	accountID string,
+	maskedEmails []fastmail.MaskedEmail,
+	query func(m *fastmail.MaskedEmail) fastmail.EmailQuery,
+) ([]fastmail.EmailQueryResult, error) {
+	queries := make([]fastmail.EmailQuery, 0, len(maskedEmails))
+	for i := range maskedEmails {
+		queries = append(queries, query(&maskedEmails[i]))
+	}
+
+	return queryEmailsInBatches(ctx, client, accountID, queries)
+}
+
+// queryEmailsInBatches returns the result of each query in the same order, mailQueryBatchSize per request.
+func queryEmailsInBatches(ctx context.Context, client *fastmail.Client, accountID string, queries []fastmail.EmailQuery) ([]fastmail.EmailQueryResult, error) {
+	results := make([]fastmail.EmailQueryResult, 0, len(queries))
+
+	for start := 0; start < len(queries); start += mailQueryBatchSize {
+		end := start + mailQueryBatchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		batch, err := client.QueryEmails(ctx, accountID, queries[start:end]...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query emails: %w", err)
+		}
+
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
+
+// addressDomain returns the lowercase domain of an email address, or an empty string if it has none.
+func addressDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(address[at+1:])), ".")
+}
+
+// registrableDomain returns the domain a hostname is registered under, eg. 'mail.example.co.uk'
+// returns 'example.co.uk', or the hostname itself if it has no public suffix.
+func registrableDomain(hostname string) string {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(hostname)
+	if err != nil {
+		return hostname
+	}
+
+	return domain
+}
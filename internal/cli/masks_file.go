@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	lockFileSuffix      = ".lock.yaml"
+	lockFilePermissions = 0o644
+)
+
+var (
+	errInvalidMasksFile = errors.New("invalid masks file")
+
+	maskKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+	// desiredStates are the states a masked email can have in a masks file.
+	desiredStates = []string{fastmail.StateEnabled, fastmail.StateDisabled}
+)
+
+// masksFile is the desired state of masked emails, kept in version control.
+type masksFile struct {
+	Masks []maskSpec `yaml:"masks"`
+}
+
+// maskSpec is the desired state of a masked email, identified by a stable local key.
+type maskSpec struct {
+	Key         string `yaml:"key"`
+	Domain      string `yaml:"domain"`
+	Description string `yaml:"description,omitempty"`
+	// State defaults to enabled.
+	State string `yaml:"state,omitempty"`
+}
+
+// lockFile records the masked email created for each key of a masks file.
+type lockFile struct {
+	Masks map[string]lockEntry `yaml:"masks"`
+}
+
+type lockEntry struct {
+	ID    string `yaml:"id"`
+	Email string `yaml:"email"`
+}
+
+// readMasksFile reads and validates a masks file.
+func readMasksFile(filename string) (*masksFile, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read masks file: %w", err)
+	}
+
+	return parseMasksFile(bytes.NewReader(b))
+}
+
+func parseMasksFile(r io.Reader) (*masksFile, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	var file masksFile
+
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %s", errInvalidMasksFile, err.Error())
+	}
+
+	keys := map[string]bool{}
+
+	for i := range file.Masks {
+		spec := &file.Masks[i]
+
+		if !maskKeyPattern.MatchString(spec.Key) {
+			return nil, fmt.Errorf("%w: mask %d: key '%s' must be letters, numbers, '.', '-' and '_'",
+				errInvalidMasksFile, i+1, spec.Key)
+		}
+
+		if keys[spec.Key] {
+			return nil, fmt.Errorf("%w: mask '%s': duplicate key", errInvalidMasksFile, spec.Key)
+		}
+
+		keys[spec.Key] = true
+
+		if strings.TrimSpace(spec.Domain) == "" {
+			return nil, fmt.Errorf("%w: mask '%s': domain is required", errInvalidMasksFile, spec.Key)
+		}
+
+		if spec.State == "" {
+			spec.State = fastmail.StateEnabled
+		}
+
+		if oneOf(desiredStates...)(spec.State) != nil {
+			return nil, fmt.Errorf("%w: mask '%s': state '%s', expected one of: %s",
+				errInvalidMasksFile, spec.Key, spec.State, strings.Join(desiredStates, ", "))
+		}
+	}
+
+	return &file, nil
+}
+
+// lockFilename returns the default lock file for a masks file, eg. masks.lock.yaml for masks.yaml.
+func lockFilename(masksFilename string) string {
+	return strings.TrimSuffix(masksFilename, filepath.Ext(masksFilename)) + lockFileSuffix
+}
+
+// readLockFile reads a lock file, a missing lock file is empty.
+func readLockFile(filename string) (*lockFile, error) {
+	lock := &lockFile{Masks: map[string]lockEntry{}}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lock, nil
+		}
+
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(b, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", filename, err)
+	}
+
+	if lock.Masks == nil {
+		lock.Masks = map[string]lockEntry{}
+	}
+
+	return lock, nil
+}
+
+func (l *lockFile) write(filename string) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "# Generated by '%s apply', maps mask keys to masked emails. Commit this file.\n", appName)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2) // nolint:gomnd // two space indent.
+
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+
+	return writeFileAtomic(filename, buf.Bytes(), lockFilePermissions)
+}
+
+// keys returns the sorted keys of the lock file.
+func (l *lockFile) keys() []string {
+	keys := make([]string, 0, len(l.Masks))
+	for key := range l.Masks {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	migrateSimpleLogin = "simplelogin"
+	migrateAddy        = "addy"
+	migrateRelay       = "relay"
+
+	defaultMigrateReport = "fastmask-migrate.csv"
+)
+
+var (
+	errUnknownMigrateFormat = errors.New("unknown migration format")
+
+	migrateFormats = []string{migrateSimpleLogin, migrateAddy, migrateRelay}
+)
+
+// migratedAlias is an alias exported from another alias service.
+type migratedAlias struct {
+	Address     string
+	Description string
+	// Domain is the website the alias was created for, if the service records it.
+	Domain  string
+	Enabled bool
+}
+
+// relayAddress is a Firefox Relay mask, as returned by its relayaddresses API.
+type relayAddress struct {
+	FullAddress  string `json:"full_address"`
+	Enabled      bool   `json:"enabled"`
+	Description  string `json:"description"`
+	GeneratedFor string `json:"generated_for"`
+	UsedOn       string `json:"used_on"`
+}
+
+func (f *fastmask) loadMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate --from <service> <export file>",
+		Short: "Create masked emails for the aliases exported from another alias service.",
+		Long: "Create a masked email for each alias exported from SimpleLogin (aliases CSV), addy.io (aliases " +
+			"CSV) or Firefox Relay (relayaddresses JSON). The description and website of each alias are " +
+			"carried over, and disabled aliases are created disabled. A CSV report maps each old address to " +
+			"its new masked email. Aliases migrated by an earlier run, recorded in the report, are skipped, " +
+			"so a partly failed migration can be run again.",
+		Example: "  fastmask migrate --from simplelogin aliases.csv\n  fastmask migrate --from relay relayaddresses.json --report relay.csv",
+		Args:    cobra.ExactArgs(1),
+		RunE:    f.runMigrate,
+	}
+
+	cmd.Flags().String(flagFrom, "", "Alias service, one of: "+strings.Join(migrateFormats, ", ")+".")
+	cmd.Flags().String(flagReport, defaultMigrateReport, "CSV report mapping each old address to its masked email.")
+	cmd.Flags().Bool(flagDryRun, false, "Show the aliases that would be migrated without creating masked emails.")
+	// nolint:errcheck // flag exists.
+	cmd.MarkFlagRequired(flagFrom)
+
+	return cmd
+}
+
+func (f *fastmask) runMigrate(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString(flagFrom)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFrom, err)
+	}
+
+	report, err := cmd.Flags().GetString(flagReport)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagReport, err)
+	}
+
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagDryRun, err)
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open export file: %w", err)
+	}
+
+	defer file.Close()
+
+	aliases, err := parseAliasExport(format, file)
+	if err != nil {
+		return err
+	}
+
+	if len(aliases) == 0 {
+		fmt.Println("No aliases found.")
+
+		return nil
+	}
+
+	previous, err := readMigrateReport(report)
+	if err != nil {
+		return err
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	current, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	existing := planMigrate(aliases, current, previous)
+
+	maskedEmails := make([]*fastmail.MaskedEmail, len(aliases))
+	pendingAliases := make([]migratedAlias, 0, len(aliases))
+	pending := make([]*fastmail.MaskedEmail, 0, len(aliases))
+
+	for i := range aliases {
+		if m, ok := existing[i]; ok {
+			maskedEmails[i] = &m
+
+			continue
+		}
+
+		maskedEmails[i] = aliases[i].maskedEmail()
+		pendingAliases = append(pendingAliases, aliases[i])
+		pending = append(pending, maskedEmails[i])
+	}
+
+	if len(existing) > 0 {
+		fmt.Printf("Skipping %d aliases already migrated.\n", len(existing))
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("No aliases left to migrate.")
+
+		return nil
+	}
+
+	if err := writeMigratePreview(os.Stdout, pendingAliases, pending); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run, %d masked emails would be created.\n", len(pending))
+
+		return nil
+	}
+
+	ok, err := confirm(cmd, fmt.Sprintf("\nCreate %d masked emails", len(pending)))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	created, createErr := createMaskedEmailsInBatches(cmd.Context(), client, pending)
+
+	results := make([]createResult, len(aliases))
+
+	for i, next := 0, 0; i < len(aliases); i++ {
+		if m, ok := existing[i]; ok {
+			results[i] = createResult{MaskedEmail: m, Existing: true}
+
+			continue
+		}
+
+		results[i] = created[next]
+		next++
+	}
+
+	if err := writeMigrateReport(report, aliases, maskedEmails, results); err != nil {
+		return err
+	}
+
+	fmt.Printf("Report written to %s.\n", report)
+
+	if reauthNeeded(createErr) {
+		fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+		return nil
+	}
+
+	return createErr
+}
+
+// planMigrate returns the existing masked email of each alias already migrated, by alias index.
+// An alias is migrated if the report of an earlier run recorded a masked email for it that still
+// exists, or a masked email has the description migrate gives aliases without one. Deleted masked
+// emails do not count, as with import.
+func planMigrate(aliases []migratedAlias, maskedEmails []fastmail.MaskedEmail, previous map[string]string) map[int]fastmail.MaskedEmail {
+	byID := map[string]fastmail.MaskedEmail{}
+	byDescription := map[string]fastmail.MaskedEmail{}
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+		if m.State == fastmail.StateDeleted {
+			continue
+		}
+
+		byID[m.ID] = *m
+		byDescription[m.Description] = *m
+	}
+
+	existing := map[int]fastmail.MaskedEmail{}
+
+	for i := range aliases {
+		if m, ok := byID[previous[strings.ToLower(aliases[i].Address)]]; ok {
+			existing[i] = m
+
+			continue
+		}
+
+		if m, ok := byDescription[(&migratedAlias{Address: aliases[i].Address}).maskedEmail().Description]; ok {
+			existing[i] = m
+		}
+	}
+
+	return existing
+}
+
+// readMigrateReport returns the masked email ID recorded for each old address by an earlier run,
+// a missing report is empty.
+func readMigrateReport(filename string) (map[string]string, error) {
+	previous := map[string]string{}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return previous, nil
+		}
+
+		return nil, fmt.Errorf("failed to open report: %w", err)
+	}
+
+	defer file.Close()
+
+	rows, err := readCSVRecords(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %w", filename, err)
+	}
+
+	for _, row := range rows {
+		if row["id"] != "" && (row["status"] == createStatusCreated || row["status"] == createStatusExisting) {
+			previous[strings.ToLower(row["old_address"])] = row["id"]
+		}
+	}
+
+	return previous, nil
+}
+
+// parseAliasExport reads the aliases of an alias service export.
+func parseAliasExport(format string, r io.Reader) ([]migratedAlias, error) {
+	switch format {
+	case migrateSimpleLogin:
+		return parseAliasCSV(r, "alias", "note", "enabled")
+	case migrateAddy:
+		return parseAliasCSV(r, "email", "description", "active")
+	case migrateRelay:
+		return parseRelayExport(r)
+	}
+
+	return nil, fmt.Errorf("%w: '%s', expected one of: %s", errUnknownMigrateFormat, format, strings.Join(migrateFormats, ", "))
+}
+
+func parseAliasCSV(r io.Reader, addressColumn, descriptionColumn, enabledColumn string) ([]migratedAlias, error) {
+	rows, err := readCSVRecords(r)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]migratedAlias, 0, len(rows))
+
+	for i, row := range rows {
+		if _, ok := row[addressColumn]; !ok {
+			return nil, fmt.Errorf("%w: row %d has no '%s' column", errInvalidImportFile, i+1, addressColumn)
+		}
+
+		enabled, err := parseAliasEnabled(row[enabledColumn])
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %s", errInvalidImportFile, i+1, err.Error())
+		}
+
+		aliases = append(aliases, migratedAlias{
+			Address:     row[addressColumn],
+			Description: row[descriptionColumn],
+			Enabled:     enabled,
+		})
+	}
+
+	return aliases, nil
+}
+
+// parseAliasEnabled parses an enabled column, which is true when empty.
+func parseAliasEnabled(s string) (bool, error) {
+	if s == "" {
+		return true, nil
+	}
+
+	enabled, err := strconv.ParseBool(strings.ToLower(s))
+	if err != nil {
+		return false, fmt.Errorf("invalid enabled value '%s'", s)
+	}
+
+	return enabled, nil
+}
+
+func parseRelayExport(r io.Reader) ([]migratedAlias, error) {
+	var addresses []relayAddress
+
+	if err := json.NewDecoder(r).Decode(&addresses); err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidImportFile, err.Error())
+	}
+
+	aliases := make([]migratedAlias, 0, len(addresses))
+
+	for _, address := range addresses {
+		domain := address.GeneratedFor
+		if domain == "" {
+			domain = strings.TrimSpace(strings.Split(address.UsedOn, ",")[0])
+		}
+
+		aliases = append(aliases, migratedAlias{
+			Address:     address.FullAddress,
+			Description: address.Description,
+			Domain:      domain,
+			Enabled:     address.Enabled,
+		})
+	}
+
+	return aliases, nil
+}
+
+// maskedEmail returns the masked email to create for the alias.
+func (a *migratedAlias) maskedEmail() *fastmail.MaskedEmail {
+	m := &fastmail.MaskedEmail{Description: a.Description, State: fastmail.StateEnabled}
+
+	if m.Description == "" {
+		m.Description = "Migrated from " + a.Address
+	}
+
+	if _, origin, ok := normalizeSite(a.Domain); ok {
+		m.ForDomain = origin
+	}
+
+	if !a.Enabled {
+		m.State = fastmail.StateDisabled
+	}
+
+	return m
+}
+
+// writeMigratePreview writes each alias with the masked email that will be created for it.
+func writeMigratePreview(out io.Writer, aliases []migratedAlias, maskedEmails []*fastmail.MaskedEmail) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ALIAS\tSTATE\tDOMAIN\tDESCRIPTION")
+
+	for i := range aliases {
+		m := maskedEmails[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", aliases[i].Address, m.State, m.ForDomain, tableCell(m.Description))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+// writeMigrateReport writes a CSV report mapping each old address to its masked email.
+func writeMigrateReport(filename string, aliases []migratedAlias, maskedEmails []*fastmail.MaskedEmail, results []createResult) error {
+	rows := [][]string{{"old_address", "new_address", "id", "state", "status", "error"}}
+
+	for i := range aliases {
+		rows = append(rows, []string{
+			aliases[i].Address,
+			results[i].MaskedEmail.Email,
+			results[i].MaskedEmail.ID,
+			maskedEmails[i].State,
+			results[i].status(),
+			results[i].Error,
+		})
+	}
+
+	var buf strings.Builder
+
+	if err := writeCSV(&buf, rows); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filename, []byte(buf.String()), exportFilePermissions)
+}
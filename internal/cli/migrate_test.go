@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Parse_Alias_Export(t *testing.T) {
+	for _, tc := range []struct {
+		format  string
+		content string
+		want    []migratedAlias
+	}{
+		{
+			format:  migrateSimpleLogin,
+			content: "alias,note,enabled,mailboxes\nshop.abc@simplelogin.com,Shop,True,me@example.com\nold.xyz@slmail.me,,False,me@example.com\n",
+			want: []migratedAlias{
+				{Address: "shop.abc@simplelogin.com", Description: "Shop", Enabled: true},
+				{Address: "old.xyz@slmail.me", Enabled: false},
+			},
+		},
+		{
+			format:  migrateAddy,
+			content: "id,local_part,domain,email,active,description\n1,news,anonaddy.me,news@anonaddy.me,1,Newsletter\n2,old,anonaddy.me,old@anonaddy.me,0,\n",
+			want: []migratedAlias{
+				{Address: "news@anonaddy.me", Description: "Newsletter", Enabled: true},
+				{Address: "old@anonaddy.me", Enabled: false},
+			},
+		},
+		{
+			format: migrateRelay,
+			content: `[{"full_address": "abc@mozmail.com", "enabled": true, "description": "Forum", "generated_for": "forum.example.org", "used_on": ""},
+				{"full_address": "xyz@mozmail.com", "enabled": false, "description": "", "generated_for": "", "used_on": "shop.example.com,example.com"}]`,
+			want: []migratedAlias{
+				{Address: "abc@mozmail.com", Description: "Forum", Domain: "forum.example.org", Enabled: true},
+				{Address: "xyz@mozmail.com", Domain: "shop.example.com", Enabled: false},
+			},
+		},
+	} {
+		aliases, err := parseAliasExport(tc.format, strings.NewReader(tc.content))
+		require.NoError(t, err, tc.format)
+		require.Equal(t, tc.want, aliases, tc.format)
+	}
+
+	_, err := parseAliasExport(migrateSimpleLogin, strings.NewReader("alias,enabled\na@simplelogin.com,maybe\n"))
+	require.ErrorIs(t, err, errInvalidImportFile)
+
+	_, err = parseAliasExport(migrateAddy, strings.NewReader("alias,active\na@anonaddy.me,1\n"))
+	require.ErrorIs(t, err, errInvalidImportFile, "missing address column")
+
+	_, err = parseAliasExport("duckduckgo", strings.NewReader(""))
+	require.ErrorIs(t, err, errUnknownMigrateFormat)
+}
+
+func Test_Migrated_Alias_Masked_Email(t *testing.T) {
+	require.Equal(t, &fastmail.MaskedEmail{
+		ForDomain:   "https://forum.example.org",
+		Description: "Forum",
+		State:       fastmail.StateEnabled,
+	}, (&migratedAlias{Address: "abc@mozmail.com", Description: "Forum", Domain: "forum.example.org", Enabled: true}).maskedEmail())
+
+	require.Equal(t, &fastmail.MaskedEmail{
+		Description: "Migrated from old@anonaddy.me",
+		State:       fastmail.StateDisabled,
+	}, (&migratedAlias{Address: "old@anonaddy.me"}).maskedEmail())
+}
+
+func Test_Plan_Migrate_Second_Run(t *testing.T) {
+	aliases := []migratedAlias{
+		{Address: "shop@simplelogin.com", Description: "Shop", Enabled: true},
+		{Address: "news@simplelogin.com", Description: "News", Enabled: true},
+		{Address: "old@simplelogin.com", Enabled: true},
+		{Address: "gone@simplelogin.com", Description: "Gone", Enabled: true},
+	}
+
+	maskedEmails := make([]*fastmail.MaskedEmail, len(aliases))
+	for i := range aliases {
+		maskedEmails[i] = aliases[i].maskedEmail()
+	}
+
+	// The first run created the shop and gone masked emails, the news batch failed.
+	report := path.Join(t.TempDir(), defaultMigrateReport)
+	require.NoError(t, writeMigrateReport(report, aliases, maskedEmails, []createResult{
+		{MaskedEmail: fastmail.MaskedEmail{ID: "masked-1", Email: "one@fastmail.com"}},
+		{Error: "rate limited"},
+		{Error: "rate limited"},
+		{MaskedEmail: fastmail.MaskedEmail{ID: "masked-9", Email: "nine@fastmail.com"}},
+	}))
+
+	previous, err := readMigrateReport(report)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"shop@simplelogin.com": "masked-1", "gone@simplelogin.com": "masked-9"}, previous)
+
+	current := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "one@fastmail.com", Description: "Shop", State: fastmail.StateEnabled},
+		// Created by a run whose report was lost, matched by its description.
+		{ID: "masked-2", Email: "two@fastmail.com", Description: "Migrated from old@simplelogin.com", State: fastmail.StateEnabled},
+		// masked-9 was deleted, so the gone alias is migrated again.
+		{ID: "masked-9", Email: "nine@fastmail.com", Description: "Gone", State: fastmail.StateDeleted},
+	}
+
+	existing := planMigrate(aliases, current, previous)
+	require.Equal(t, map[int]fastmail.MaskedEmail{0: current[0], 2: current[1]}, existing)
+
+	_, err = readMigrateReport(path.Join(t.TempDir(), "missing.csv"))
+	require.NoError(t, err)
+}
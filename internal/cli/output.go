@@ -2,12 +2,190 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagOutput = "output"
+
+	outputJSON  = "json"
+	outputTable = "table"
 )
 
+var outputFormats = []string{outputJSON, outputTable}
+
+// writeOutput writes o to stdout in the configured output format, types without a table
+// representation are always written as JSON.
+func (f *fastmask) writeOutput(o interface{}) error {
+	if f.config.output == outputTable {
+		switch v := o.(type) {
+		case *fastmail.MaskedEmail:
+			return writeMaskedEmailDetail(os.Stdout, v)
+		case []fastmail.MaskedEmail:
+			return writeMaskedEmailTable(os.Stdout, v)
+		case []fastmail.MaskedEmailChange:
+			return writeMaskedEmailChanges(os.Stdout, v)
+		case []leakReport:
+			return writeLeakReports(os.Stdout, v)
+		case []maskStats:
+			return writeMailStatsTable(os.Stdout, v)
+		case []policyAction:
+			return writePolicyActions(os.Stdout, v)
+		}
+	}
+
+	return writeOutput(o)
+}
+
 func writeOutput(o interface{}) error {
-	encoder := json.NewEncoder(os.Stdout)
+	return writeJSON(os.Stdout, o)
+}
+
+// writeJSON writes o to out as indented JSON.
+func writeJSON(out io.Writer, o interface{}) error {
+	encoder := json.NewEncoder(out)
 	encoder.SetIndent("", "  ")
-	// nolint:errcheck,wrapcheck // ignore error, we are writing to stdout
-	return encoder.Encode(o)
+
+	if err := encoder.Encode(o); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
+	}
+
+	return nil
+}
+
+func writeMaskedEmailTable(out io.Writer, maskedEmails []fastmail.MaskedEmail) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ID\tEMAIL\tSTATE\tDOMAIN\tDESCRIPTION\tCREATED")
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", m.ID, m.Email, m.State, m.ForDomain, tableCell(m.Description), m.CreatedAt)
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func writeMaskedEmailDetail(out io.Writer, m *fastmail.MaskedEmail) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	for _, field := range [][2]string{
+		{"ID", m.ID},
+		{"Email", m.Email},
+		{"State", m.State},
+		{"Domain", m.ForDomain},
+		{"Description", m.Description},
+		{"URL", m.URL},
+		{"Created By", m.CreatedBy},
+		{"Created At", m.CreatedAt},
+		{"Last Message At", m.LastMessageAt},
+	} {
+		fmt.Fprintf(w, "%s:\t%s\n", field[0], tableCell(field[1]))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func writeMaskedEmailChanges(out io.Writer, changes []fastmail.MaskedEmailChange) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "CHANGE\tEMAIL\tID\tDETAILS")
+
+	for i := range changes {
+		c := &changes[i]
+
+		var details []string
+
+		switch c.Type {
+		case fastmail.ChangeCreated:
+			details = append(details, c.New.State, c.New.ForDomain, fmt.Sprintf("%q", c.New.Description))
+		case fastmail.ChangeUpdated:
+			for _, field := range c.Fields {
+				oldValue, newValue := changeFieldValue(c.Old, field), changeFieldValue(c.New, field)
+				details = append(details, fmt.Sprintf("%s: %q -> %q", field, oldValue, newValue))
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Type, c.Email, c.ID, tableCell(strings.Join(details, ", ")))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func writeLeakReports(out io.Writer, reports []leakReport) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "EMAIL\tDOMAIN\tUNRELATED\tSENDERS")
+
+	for i := range reports {
+		r := &reports[i]
+
+		senders := make([]string, 0, len(r.Senders))
+		for _, s := range r.Senders {
+			senders = append(senders, fmt.Sprintf("%s (%d since %s)", s.Domain, s.Messages, s.FirstSeen))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", r.Email, r.Domain, r.UnrelatedMessages, r.Messages, tableCell(strings.Join(senders, ", ")))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func writeMailStatsTable(out io.Writer, stats []maskStats) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "EMAIL\tSTATE\tWINDOW\tMESSAGES\tJUNK\tLAST MESSAGE\tLAST SENDER\tTOP SENDERS")
+
+	for i := range stats {
+		s := &stats[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", s.Email, s.State, s.Window, s.Messages, s.Junk,
+			s.LastMessageAt, s.LastSender, tableCell(formatSenderCounts(s.TopSenders, ", ", "%s (%d)")))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func writePolicyActions(out io.Writer, actions []policyAction) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ACTION\tEMAIL\tSTATE\tDOMAIN\tRULE\tREASONS")
+
+	for i := range actions {
+		a := &actions[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", a.Action, a.Email, a.State, a.Domain, tableCell(a.Rule), tableCell(strings.Join(a.Reasons, ", ")))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func changeFieldValue(m *fastmail.MaskedEmail, field string) string {
+	switch field {
+	case fastmail.FieldState:
+		return m.State
+	case fastmail.FieldForDomain:
+		return m.ForDomain
+	case fastmail.FieldDescription:
+		return m.Description
+	case fastmail.FieldURL:
+		return m.URL
+	}
+
+	return ""
+}
+
+// tableCell replaces characters that would break the table layout, keeping byte offsets intact.
+func tableCell(s string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s)
 }
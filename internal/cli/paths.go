@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+const (
+	configFilename       = "config.yaml"
+	legacyConfigFilename = ".config.yaml"
+	legacyDirname        = ".fastmask"
+	legacyBackupExt      = ".bak"
+
+	configFilePermissions = 0o600
+
+	envXDGConfigHome = "XDG_CONFIG_HOME"
+	envXDGStateHome  = "XDG_STATE_HOME"
+	envXDGCacheHome  = "XDG_CACHE_HOME"
+)
+
+// dirs are the directories fastmask reads and writes files in.
+type dirs struct {
+	// config holds the config file and encrypted credentials.
+	config string
+	// state holds data that should persist between runs, but is not configuration.
+	state string
+	// cache holds data that can be safely deleted.
+	cache string
+	// legacy is the directory used by earlier versions, and the fallback when XDG variables are unset.
+	legacy string
+}
+
+// resolveDirs returns the directories from XDG_CONFIG_HOME, XDG_STATE_HOME and XDG_CACHE_HOME,
+// falling back to ~/.fastmask when they are not set.
+func resolveDirs() (*dirs, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine home directory location: %w", err)
+	}
+
+	legacy := path.Join(home, legacyDirname)
+
+	xdgDir := func(env, fallback string) string {
+		if base := os.Getenv(env); path.IsAbs(base) {
+			return path.Join(base, appName)
+		}
+
+		return fallback
+	}
+
+	return &dirs{
+		config: xdgDir(envXDGConfigHome, legacy),
+		state:  xdgDir(envXDGStateHome, path.Join(legacy, "state")),
+		cache:  xdgDir(envXDGCacheHome, path.Join(legacy, "cache")),
+		legacy: legacy,
+	}, nil
+}
+
+// configFile returns the default config file path.
+func (d *dirs) configFile() string {
+	return path.Join(d.config, configFilename)
+}
+
+// migrateLegacyFiles moves files written by earlier versions to their current location. Earlier
+// versions created '~/.fastmask/.config.yaml' but read '~/.fastmask/config.yaml'.
+func (d *dirs) migrateLegacyFiles() error {
+	moves := []struct {
+		from string
+		to   string
+	}{
+		{from: path.Join(d.legacy, configFilename), to: d.configFile()},
+		{from: path.Join(d.legacy, legacyConfigFilename), to: d.configFile()},
+		{from: path.Join(d.legacy, credentialsFilename), to: path.Join(d.config, credentialsFilename)},
+	}
+
+	for _, m := range moves {
+		if m.from == m.to {
+			continue
+		}
+
+		info, err := os.Stat(m.from)
+		if err != nil {
+			continue
+		}
+
+		// Earlier versions created an empty file on first run, it can be removed.
+		if info.Size() == 0 {
+			if err := os.Remove(m.from); err != nil {
+				return fmt.Errorf("failed to remove empty legacy file: %w", err)
+			}
+
+			continue
+		}
+
+		// Move the file aside so the warning is only shown once.
+		if _, err := os.Stat(m.to); err == nil {
+			backup := m.from + legacyBackupExt
+			if _, err := os.Stat(backup); err == nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Ignoring %s, %s already exists.\n", m.from, m.to)
+
+				continue
+			}
+
+			if err := os.Rename(m.from, backup); err != nil {
+				return fmt.Errorf("failed to rename %s: %w", m.from, err)
+			}
+
+			fmt.Fprintf(os.Stderr, "⚠️  Moved %s to %s, %s already exists.\n", m.from, backup, m.to)
+
+			continue
+		}
+
+		if err := moveFile(m.from, m.to); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "🚚 Moved %s to %s.\n", m.from, m.to)
+	}
+
+	return nil
+}
+
+// moveFile renames from to to, copying the file if they are on different filesystems.
+func moveFile(from, to string) error {
+	if err := os.MkdirAll(path.Dir(to), configDirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", from, err)
+	}
+
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, configFilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", to, err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+
+		return fmt.Errorf("failed to copy %s: %w", from, err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", to, err)
+	}
+
+	if err := os.Remove(from); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", from, err)
+	}
+
+	return nil
+}
+
+// enforceFilePermissions restricts filename to 0600, warning if it was group or world readable.
+func enforceFilePermissions(filename string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return
+	}
+
+	perm := info.Mode().Perm()
+	if perm&0o077 == 0 {
+		return
+	}
+
+	if err := os.Chmod(filename, configFilePermissions); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %s is accessible by other users (%#o), please run 'chmod 600 %s'.\n", filename, perm, filename)
+
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "⚠️  %s was accessible by other users (%#o), permissions changed to %#o.\n", filename, perm, configFilePermissions)
+}
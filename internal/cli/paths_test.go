@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// captureStderr returns what fn writes to stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	stderr := os.Stderr
+	os.Stderr = w
+
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+
+	require.NoError(t, w.Close())
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+// readFiles returns the contents of the regular files under dir by path relative to it.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+
+	var walk func(rel string)
+	walk = func(rel string) {
+		entries, err := os.ReadDir(path.Join(dir, rel))
+		require.NoError(t, err)
+
+		for _, entry := range entries {
+			name := path.Join(rel, entry.Name())
+			if entry.IsDir() {
+				walk(name)
+
+				continue
+			}
+
+			b, err := os.ReadFile(path.Join(dir, name))
+			require.NoError(t, err)
+
+			files[name] = string(b)
+		}
+	}
+
+	walk("")
+
+	return files
+}
+
+func Test_Resolve_Dirs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		xdg  map[string]string
+		want dirs
+	}{
+		"Unset": {
+			want: dirs{config: ".fastmask", state: ".fastmask/state", cache: ".fastmask/cache"},
+		},
+		"Set": {
+			xdg:  map[string]string{envXDGConfigHome: "config", envXDGStateHome: "state", envXDGCacheHome: "cache"},
+			want: dirs{config: "config/fastmask", state: "state/fastmask", cache: "cache/fastmask"},
+		},
+		"Config Only": {
+			xdg:  map[string]string{envXDGConfigHome: "config"},
+			want: dirs{config: "config/fastmask", state: ".fastmask/state", cache: ".fastmask/cache"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			home := setupHome(t)
+
+			for env, dir := range tc.xdg {
+				t.Setenv(env, path.Join(home, dir))
+			}
+
+			d, err := resolveDirs()
+			require.NoError(t, err)
+			require.Equal(t, &dirs{
+				config: path.Join(home, tc.want.config),
+				state:  path.Join(home, tc.want.state),
+				cache:  path.Join(home, tc.want.cache),
+				legacy: path.Join(home, legacyDirname),
+			}, d)
+		})
+	}
+
+	// Relative XDG paths are ignored, as the specification requires.
+	home := setupHome(t)
+	t.Setenv(envXDGConfigHome, "relative")
+
+	d, err := resolveDirs()
+	require.NoError(t, err)
+	require.Equal(t, path.Join(home, legacyDirname), d.config)
+}
+
+func Test_Migrate_Legacy_Files(t *testing.T) {
+	for name, tc := range map[string]struct {
+		xdgConfig bool
+		files     map[string]string
+		want      map[string]string
+	}{
+		"Legacy Config Renamed": {
+			files: map[string]string{".fastmask/.config.yaml": "output: table\n"},
+			want:  map[string]string{".fastmask/config.yaml": "output: table\n"},
+		},
+		"Moved To XDG": {
+			xdgConfig: true,
+			files: map[string]string{
+				".fastmask/config.yaml":     "output: table\n",
+				".fastmask/credentials.enc": "{}",
+			},
+			want: map[string]string{
+				"xdg/fastmask/config.yaml":     "output: table\n",
+				"xdg/fastmask/credentials.enc": "{}",
+			},
+		},
+		"Empty Legacy Config Removed": {
+			files: map[string]string{".fastmask/.config.yaml": "", ".fastmask/config.yaml": "output: json\n"},
+			want:  map[string]string{".fastmask/config.yaml": "output: json\n"},
+		},
+		"Destination Exists": {
+			files: map[string]string{".fastmask/.config.yaml": "output: table\n", ".fastmask/config.yaml": "output: json\n"},
+			want: map[string]string{
+				".fastmask/config.yaml":      "output: json\n",
+				".fastmask/.config.yaml.bak": "output: table\n",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			home := setupHome(t)
+			if tc.xdgConfig {
+				t.Setenv(envXDGConfigHome, path.Join(home, "xdg"))
+			}
+
+			for name, content := range tc.files {
+				require.NoError(t, os.MkdirAll(path.Dir(path.Join(home, name)), configDirectoryPermissions))
+				require.NoError(t, os.WriteFile(path.Join(home, name), []byte(content), configFilePermissions))
+			}
+
+			d, err := resolveDirs()
+			require.NoError(t, err)
+
+			require.NoError(t, d.migrateLegacyFiles())
+			require.Equal(t, tc.want, readFiles(t, home))
+
+			// Running again changes nothing and warns about nothing.
+			require.Empty(t, captureStderr(t, func() { require.NoError(t, d.migrateLegacyFiles()) }))
+			require.Equal(t, tc.want, readFiles(t, home))
+		})
+	}
+}
+
+func Test_Enforce_File_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not enforced on windows")
+	}
+
+	filename := path.Join(t.TempDir(), configFilename)
+	require.NoError(t, os.WriteFile(filename, []byte("output: json\n"), 0o600))
+	require.NoError(t, os.Chmod(filename, 0o644))
+
+	require.Contains(t, captureStderr(t, func() { enforceFilePermissions(filename) }), "was accessible by other users (0644)")
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(configFilePermissions), info.Mode().Perm())
+
+	// Restricted files are left alone without a warning.
+	require.Empty(t, captureStderr(t, func() { enforceFilePermissions(filename) }))
+}
@@ -0,0 +1,346 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagFile = "file"
+	flagLock = "lock"
+
+	planCreate  = "create"
+	planUpdate  = "update"
+	planDisable = "disable"
+)
+
+// planAction is a change needed to reconcile masked emails with a masks file.
+type planAction struct {
+	Type string
+	Key  string
+	// Spec is the desired masked email, set for create and update actions.
+	Spec *maskSpec
+	// Current is the existing masked email, set for update and disable actions.
+	Current *fastmail.MaskedEmail
+	// Update holds the changed fields of update and disable actions.
+	Update fastmail.MaskedEmailUpdate
+	// Replaces is the ID recorded in the lock file for a create action when that masked email no
+	// longer exists.
+	Replaces string
+}
+
+func (f *fastmask) loadPlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan -f <masks.yaml>",
+		Short: "Show the changes needed to match a masks file.",
+		Long: "Compare the masked emails described in a masks file to your account and show the masked " +
+			"emails that 'fastmask apply' would create, update or disable. Masked emails are matched to " +
+			"their key with the lock file written by apply, masks.lock.yaml for masks.yaml.",
+		Example: "  fastmask plan -f masks.yaml",
+		Args:    cobra.NoArgs,
+		RunE:    f.runPlan,
+	}
+
+	addMasksFileFlags(cmd)
+
+	return cmd
+}
+
+func (f *fastmask) loadApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply -f <masks.yaml>",
+		Short: "Create, update and disable masked emails to match a masks file.",
+		Long: "Create, update and disable masked emails to match a masks file, after confirming the plan. " +
+			"The masked email created for each key is recorded in the lock file, so applying again only " +
+			"changes what differs. Masks removed from the file are disabled, not deleted.",
+		Example: "  fastmask apply -f masks.yaml\n  fastmask apply -f masks.yaml --no-confirm",
+		Args:    cobra.NoArgs,
+		RunE:    f.runApply,
+	}
+
+	addMasksFileFlags(cmd)
+
+	return cmd
+}
+
+func addMasksFileFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(flagFile, "f", "", "Masks file describing the desired masked emails.")
+	cmd.Flags().String(flagLock, "", "Lock file (default is the masks file name ending in .lock.yaml).")
+	// nolint:errcheck // flag exists.
+	cmd.MarkFlagRequired(flagFile)
+}
+
+// masksFileFromFlags reads the masks file and lock file selected by flags, returning the lock file name.
+func masksFileFromFlags(cmd *cobra.Command) (*masksFile, *lockFile, string, error) {
+	filename, err := cmd.Flags().GetString(flagFile)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to get flag %s: %w", flagFile, err)
+	}
+
+	lockName, err := cmd.Flags().GetString(flagLock)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to get flag %s: %w", flagLock, err)
+	}
+
+	if lockName == "" {
+		lockName = lockFilename(filename)
+	}
+
+	desired, err := readMasksFile(filename)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	lock, err := readLockFile(lockName)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return desired, lock, lockName, nil
+}
+
+func (f *fastmask) runPlan(cmd *cobra.Command, _ []string) error {
+	desired, lock, _, err := masksFileFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	plan := buildPlan(desired, lock, maskedEmails)
+	if len(plan) == 0 {
+		fmt.Println("No changes, masked emails match the masks file.")
+
+		return nil
+	}
+
+	writePlan(os.Stdout, plan)
+
+	return nil
+}
+
+func (f *fastmask) runApply(cmd *cobra.Command, _ []string) error {
+	desired, lock, lockName, err := masksFileFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	plan := buildPlan(desired, lock, maskedEmails)
+	if len(plan) == 0 {
+		fmt.Println("No changes, masked emails match the masks file.")
+
+		return nil
+	}
+
+	writePlan(os.Stdout, plan)
+
+	ok, err := confirm(cmd, "\nApply these changes")
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	applyErr := applyPlan(cmd.Context(), client, plan, lock)
+
+	// Record created masked emails even if some changes failed, so they are not created twice.
+	if err := lock.write(lockName); err != nil {
+		return err
+	}
+
+	if applyErr != nil {
+		return applyErr
+	}
+
+	fmt.Printf("Applied %d changes, lock file written to %s.\n", len(plan), lockName)
+
+	return nil
+}
+
+// buildPlan returns the actions needed for the masked emails to match the masks file, in the
+// order of the masks file followed by disabled keys in sorted order.
+func buildPlan(desired *masksFile, lock *lockFile, maskedEmails []fastmail.MaskedEmail) []planAction {
+	byID := make(map[string]*fastmail.MaskedEmail, len(maskedEmails))
+	for i := range maskedEmails {
+		byID[maskedEmails[i].ID] = &maskedEmails[i]
+	}
+
+	var plan []planAction
+
+	wanted := make(map[string]bool, len(desired.Masks))
+
+	for i := range desired.Masks {
+		spec := &desired.Masks[i]
+		wanted[spec.Key] = true
+
+		entry, locked := lock.Masks[spec.Key]
+
+		current := byID[entry.ID]
+		if !locked || current == nil {
+			plan = append(plan, planAction{Type: planCreate, Key: spec.Key, Spec: spec, Replaces: entry.ID})
+
+			continue
+		}
+
+		if update := specUpdate(spec, current); update != (fastmail.MaskedEmailUpdate{}) {
+			plan = append(plan, planAction{Type: planUpdate, Key: spec.Key, Spec: spec, Current: current, Update: update})
+		}
+	}
+
+	for _, key := range lock.keys() {
+		current := byID[lock.Masks[key].ID]
+		if wanted[key] || current == nil || current.State == fastmail.StateDisabled || current.State == fastmail.StateDeleted {
+			continue
+		}
+
+		state := fastmail.StateDisabled
+		plan = append(plan, planAction{Type: planDisable, Key: key, Current: current, Update: fastmail.MaskedEmailUpdate{State: &state}})
+	}
+
+	return plan
+}
+
+// specUpdate returns the fields of current that differ from spec.
+func specUpdate(spec *maskSpec, current *fastmail.MaskedEmail) fastmail.MaskedEmailUpdate {
+	var update fastmail.MaskedEmailUpdate
+
+	if spec.Domain != current.ForDomain {
+		domain := spec.Domain
+		update.ForDomain = &domain
+	}
+
+	if spec.Description != current.Description {
+		description := spec.Description
+		update.Description = &description
+	}
+
+	if spec.State != current.State {
+		state := spec.State
+		update.State = &state
+	}
+
+	return update
+}
+
+// applyPlan creates and updates masked emails in one request each, recording created masked
+// emails in the lock file.
+func applyPlan(ctx context.Context, client *fastmail.Client, plan []planAction, lock *lockFile) error {
+	creates := map[string]*fastmail.MaskedEmail{}
+	keys := map[string]string{}
+	updates := map[string]fastmail.MaskedEmailUpdate{}
+
+	for i := range plan {
+		action := &plan[i]
+
+		switch action.Type {
+		case planCreate:
+			// Keys are not necessarily valid creation IDs, so creation IDs are generated.
+			creationID := fmt.Sprintf("c%d", i)
+			keys[creationID] = action.Key
+			creates[creationID] = &fastmail.MaskedEmail{
+				ForDomain:   action.Spec.Domain,
+				Description: action.Spec.Description,
+				State:       action.Spec.State,
+			}
+		case planUpdate, planDisable:
+			updates[action.Current.ID] = action.Update
+		}
+	}
+
+	var createErr, updateErr error
+
+	if len(creates) > 0 {
+		var created map[string]fastmail.MaskedEmail
+
+		created, createErr = client.CreateMaskedEmails(ctx, creates)
+
+		for creationID, m := range created {
+			lock.Masks[keys[creationID]] = lockEntry{ID: m.ID, Email: m.Email}
+		}
+
+		var setErrs fastmail.SetErrors
+		if createErr != nil && !errors.As(createErr, &setErrs) {
+			return fmt.Errorf("failed to create masked emails: %w", createErr)
+		}
+	}
+
+	if len(updates) > 0 {
+		updateErr = client.UpdateMaskedEmails(ctx, updates)
+	}
+
+	switch {
+	case createErr != nil && updateErr != nil:
+		return fmt.Errorf("failed to create masked emails: %w, and failed to update masked emails: %s", createErr, updateErr.Error())
+	case createErr != nil:
+		return fmt.Errorf("failed to create masked emails: %w", createErr)
+	case updateErr != nil:
+		return fmt.Errorf("failed to update masked emails: %w", updateErr)
+	}
+
+	return nil
+}
+
+// writePlan writes the plan in a human readable form.
+func writePlan(out io.Writer, plan []planAction) {
+	counts := map[string]int{}
+
+	for i := range plan {
+		action := &plan[i]
+		counts[action.Type]++
+
+		switch action.Type {
+		case planCreate:
+			fmt.Fprintf(out, "+ create   %s: %s %q (%s)\n", action.Key, action.Spec.Domain, action.Spec.Description, action.Spec.State)
+
+			if action.Replaces != "" {
+				fmt.Fprintf(out, "  replaces %s, which no longer exists\n", action.Replaces)
+			}
+		case planUpdate:
+			fmt.Fprintf(out, "~ update   %s: %s (%s)\n", action.Key, action.Current.Email, action.Current.ID)
+			writeEditSummaryFields(out, action.Current, action.Update)
+		case planDisable:
+			fmt.Fprintf(out, "- disable  %s: %s (%s), removed from the masks file\n", action.Key, action.Current.Email, action.Current.ID)
+		}
+	}
+
+	fmt.Fprintf(out, "\nPlan: %d to create, %d to update, %d to disable.\n", counts[planCreate], counts[planUpdate], counts[planDisable])
+}
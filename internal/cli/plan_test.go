@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Masks_File(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		file, err := parseMasksFile(strings.NewReader("masks:\n  - key: github\n    domain: https://github.com\n"))
+		require.NoError(t, err)
+		require.Len(t, file.Masks, 1)
+		require.Equal(t, fastmail.StateEnabled, file.Masks[0].State)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"key":       "masks:\n  - key: 'a b'\n    domain: example.com\n",
+			"duplicate": "masks:\n  - key: a\n    domain: example.com\n  - key: a\n    domain: example.org\n",
+			"domain":    "masks:\n  - key: a\n",
+			"state":     "masks:\n  - key: a\n    domain: example.com\n    state: pending\n",
+			"field":     "masks:\n  - key: a\n    domain: example.com\n    email: a@fastmail.com\n",
+		} {
+			_, err := parseMasksFile(strings.NewReader(content))
+			require.ErrorIs(t, err, errInvalidMasksFile, name)
+		}
+	})
+
+	t.Run("Lock File", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := lockFilename(filepath.Join(dir, "masks.yaml"))
+		require.Equal(t, filepath.Join(dir, "masks.lock.yaml"), filename)
+
+		lock, err := readLockFile(filename)
+		require.NoError(t, err)
+		require.Empty(t, lock.Masks)
+
+		lock.Masks["github"] = lockEntry{ID: "masked-1", Email: "one@fastmail.com"}
+		require.NoError(t, lock.write(filename))
+
+		read, err := readLockFile(filename)
+		require.NoError(t, err)
+		require.Equal(t, lock, read)
+	})
+}
+
+func Test_Build_Plan(t *testing.T) {
+	desired := &masksFile{Masks: []maskSpec{
+		{Key: "github", Domain: "https://github.com", Description: "GitHub", State: "enabled"},
+		{Key: "shop", Domain: "https://shop.example.com", Description: "Shop", State: "disabled"},
+		{Key: "new", Domain: "https://new.example.com", State: "enabled"},
+		{Key: "gone", Domain: "https://gone.example.com", State: "enabled"},
+	}}
+
+	lock := &lockFile{Masks: map[string]lockEntry{
+		"github": {ID: "masked-1"},
+		"shop":   {ID: "masked-2"},
+		"gone":   {ID: "masked-9"},
+		"old":    {ID: "masked-3"},
+	}}
+
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "one@fastmail.com", ForDomain: "https://github.com", Description: "GitHub", State: "enabled"},
+		{ID: "masked-2", Email: "two@fastmail.com", ForDomain: "https://shop.example.com", Description: "Old shop", State: "enabled"},
+		{ID: "masked-3", Email: "three@fastmail.com", ForDomain: "https://old.example.com", State: "enabled"},
+	}
+
+	plan := buildPlan(desired, lock, maskedEmails)
+	require.Len(t, plan, 4)
+
+	require.Equal(t, planUpdate, plan[0].Type)
+	require.Equal(t, "shop", plan[0].Key)
+	require.Equal(t, "Shop", *plan[0].Update.Description)
+	require.Equal(t, "disabled", *plan[0].Update.State)
+	require.Nil(t, plan[0].Update.ForDomain)
+
+	require.Equal(t, planCreate, plan[1].Type)
+	require.Equal(t, "new", plan[1].Key)
+	require.Empty(t, plan[1].Replaces)
+
+	require.Equal(t, planCreate, plan[2].Type)
+	require.Equal(t, "gone", plan[2].Key)
+	require.Equal(t, "masked-9", plan[2].Replaces)
+
+	require.Equal(t, planDisable, plan[3].Type)
+	require.Equal(t, "old", plan[3].Key)
+
+	var buf bytes.Buffer
+
+	writePlan(&buf, plan)
+	require.Contains(t, buf.String(), "~ update   shop: two@fastmail.com (masked-2)\n  state: \"enabled\" -> \"disabled\"\n"+
+		"  description: \"Old shop\" -> \"Shop\"\n")
+	require.Contains(t, buf.String(), "Plan: 2 to create, 1 to update, 1 to disable.")
+
+	t.Run("Idempotent", func(t *testing.T) {
+		lock.Masks["new"] = lockEntry{ID: "masked-4"}
+		lock.Masks["gone"] = lockEntry{ID: "masked-5"}
+
+		maskedEmails[1].Description, maskedEmails[1].State = "Shop", "disabled"
+		maskedEmails[2].State = "disabled"
+		maskedEmails = append(maskedEmails,
+			fastmail.MaskedEmail{ID: "masked-4", ForDomain: "https://new.example.com", State: "enabled"},
+			fastmail.MaskedEmail{ID: "masked-5", ForDomain: "https://gone.example.com", State: "enabled"},
+		)
+
+		require.Empty(t, buildPlan(desired, lock, maskedEmails))
+	})
+}
@@ -0,0 +1,527 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	policyDisable = "disable"
+	policyDelete  = "delete"
+
+	defaultJunkWindow = "7d"
+)
+
+var (
+	errInvalidPolicy = errors.New("invalid policy file")
+
+	policyActions = []string{policyDisable, policyDelete}
+)
+
+// policyFile is a list of rules disabling or deleting masked emails, evaluated in order.
+type policyFile struct {
+	Rules []policyRule `yaml:"rules"`
+}
+
+// policyRule selects masked emails matching all of its conditions. At least one condition is
+// required, so a rule can not select every masked email.
+type policyRule struct {
+	Name   string `yaml:"name"`
+	Action string `yaml:"action"`
+	// State, Domain and CreatedBy select masked emails as the filter flags do.
+	State     []string `yaml:"state,omitempty"`
+	Domain    string   `yaml:"domain,omitempty"`
+	CreatedBy []string `yaml:"createdBy,omitempty"`
+	// OlderThan matches masked emails created longer ago than the duration.
+	OlderThan string `yaml:"olderThan,omitempty"`
+	// InactiveFor matches masked emails without messages within the duration.
+	InactiveFor string `yaml:"inactiveFor,omitempty"`
+	// Junk matches masked emails with more junk messages than Over within Window.
+	Junk *junkCondition `yaml:"junk,omitempty"`
+	// SenderMismatch matches masked emails receiving mail from senders unrelated to their
+	// domain, other than the Allow domains, as 'fastmask leaks' reports.
+	SenderMismatch bool     `yaml:"senderMismatch,omitempty"`
+	Allow          []string `yaml:"allow,omitempty"`
+
+	filter     *fastmail.MaskedEmailFilter
+	junkWindow time.Duration
+}
+
+type junkCondition struct {
+	Over   int    `yaml:"over"`
+	Window string `yaml:"window,omitempty"`
+}
+
+// policyAction is a masked email a rule disables or deletes, with the reasons it matched.
+type policyAction struct {
+	Rule    string   `json:"rule"`
+	Action  string   `json:"action"`
+	ID      string   `json:"id"`
+	Email   string   `json:"email"`
+	Domain  string   `json:"domain"`
+	State   string   `json:"state"`
+	Reasons []string `json:"reasons"`
+}
+
+// policyMail looks up the mail conditions of rules, for the masked emails matching the other
+// conditions.
+type policyMail interface {
+	// junk returns the number of junk messages each masked email received within the window.
+	junk(ctx context.Context, maskedEmails []fastmail.MaskedEmail, window time.Duration) (map[string]int, error)
+	// unrelatedSenders returns the leak report of each masked email receiving mail from unrelated senders.
+	unrelatedSenders(ctx context.Context, maskedEmails []fastmail.MaskedEmail, allow []string) (map[string]leakReport, error)
+}
+
+func (f *fastmask) loadPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Disable and delete masked emails with policy rules.",
+		Long: "Evaluate a YAML policy file of rules, each disabling or deleting the masked emails matching all " +
+			"of its conditions. Rules are evaluated in order and each masked email is only acted on by the " +
+			"first rule it matches. Delete sets masked emails to the deleted state, so they can be restored.\n\n" +
+			"Rules match masked emails with state, domain, createdBy, olderThan, inactiveFor, junk " +
+			"(more than 'over' junk messages within 'window', 7d by default) and senderMismatch (mail from " +
+			"senders unrelated to the domain, ignoring 'allow' domains). Junk and senderMismatch need an API " +
+			"token with access to email.",
+		Example: "  # policy.yaml\n" +
+			"  rules:\n" +
+			"    - name: inactive\n" +
+			"      action: disable\n" +
+			"      state: [enabled]\n" +
+			"      inactiveFor: 365d\n" +
+			"    - name: junk\n" +
+			"      action: disable\n" +
+			"      state: [enabled]\n" +
+			"      junk: {over: 5, window: 7d}\n" +
+			"    - name: abandoned\n" +
+			"      action: delete\n" +
+			"      state: [pending]\n" +
+			"      olderThan: 1d\n\n" +
+			"  fastmask policy apply -f policy.yaml --dry-run",
+	}
+
+	apply := &cobra.Command{
+		Use:   "apply -f <policy.yaml>",
+		Short: "Apply the rules of a policy file.",
+		Long: "Show the masked emails the rules of a policy file disable or delete, with the reasons each " +
+			"matched, then apply the actions after confirming. Use -o json for a report of the actions.",
+		Example: "  fastmask policy apply -f policy.yaml --dry-run\n  fastmask policy apply -f policy.yaml --no-confirm -o json",
+		Args:    cobra.NoArgs,
+		RunE:    f.runPolicyApply,
+	}
+
+	apply.Flags().StringP(flagFile, "f", "", "Policy file with the rules to apply.")
+	apply.Flags().Bool(flagDryRun, false, "Show the actions without applying them.")
+	// nolint:errcheck // flag exists.
+	apply.MarkFlagRequired(flagFile)
+
+	cmd.AddCommand(apply)
+
+	return cmd
+}
+
+func (f *fastmask) runPolicyApply(cmd *cobra.Command, _ []string) error {
+	filename, err := cmd.Flags().GetString(flagFile)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFile, err)
+	}
+
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagDryRun, err)
+	}
+
+	now := time.Now()
+
+	policy, err := readPolicyFile(filename, now)
+	if err != nil {
+		return err
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	actions, err := f.evaluatePolicyFile(cmd.Context(), client, policy, now)
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return err
+	}
+
+	if len(actions) == 0 {
+		fmt.Fprintln(os.Stderr, "No masked emails matched the policy.")
+
+		return nil
+	}
+
+	if err := f.writeOutput(actions); err != nil {
+		return err
+	}
+
+	ids := policyActionIDs(actions)
+	disabled, deleted := len(ids[policyDisable]), len(ids[policyDelete])
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "\nDry run, %d masked emails would be disabled and %d deleted.\n", disabled, deleted)
+
+		return nil
+	}
+
+	ok, err := confirm(cmd, fmt.Sprintf("\nConfirm %d masked emails will be disabled and %d deleted", disabled, deleted))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	if err := applyPolicyActions(cmd.Context(), client, ids); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ %d masked emails disabled and %d deleted.\n", disabled, deleted)
+
+	return nil
+}
+
+func (f *fastmask) evaluatePolicyFile(ctx context.Context, client *fastmail.Client, policy *policyFile, now time.Time) ([]policyAction, error) {
+	maskedEmails, err := client.GetMaskedEmails(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	return evaluatePolicy(ctx, policy, maskedEmails, &clientPolicyMail{f: f, client: client, now: now})
+}
+
+// readPolicyFile reads and validates a policy file, with durations relative to now.
+func readPolicyFile(filename string, now time.Time) (*policyFile, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	return parsePolicyFile(bytes.NewReader(b), now)
+}
+
+// nolint:cyclop // validates each field.
+func parsePolicyFile(r io.Reader, now time.Time) (*policyFile, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	var file policyFile
+
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %s", errInvalidPolicy, err.Error())
+	}
+
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		invalid := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: rule '%s': %s", errInvalidPolicy, rule.Name, fmt.Sprintf(format, args...))
+		}
+
+		if oneOf(policyActions...)(rule.Action) != nil {
+			return nil, invalid("action '%s', expected one of: %s", rule.Action, strings.Join(policyActions, ", "))
+		}
+
+		rule.filter = &fastmail.MaskedEmailFilter{States: rule.State, CreatedBy: rule.CreatedBy, Now: now}
+
+		for _, state := range rule.State {
+			if oneOf(maskedEmailStates...)(strings.ToLower(state)) != nil {
+				return nil, invalid("state '%s', expected one of: %s", state, strings.Join(maskedEmailStates, ", "))
+			}
+		}
+
+		if err := setDomainFilter(rule.filter, rule.Domain); err != nil {
+			return nil, invalid("%s", err)
+		}
+
+		if rule.OlderThan != "" {
+			olderThan, err := parseDuration(rule.OlderThan)
+			if err != nil {
+				return nil, invalid("olderThan: %s", err)
+			}
+
+			rule.filter.Until = now.Add(-olderThan)
+		}
+
+		if rule.InactiveFor != "" {
+			inactiveFor, err := parseDuration(rule.InactiveFor)
+			if err != nil {
+				return nil, invalid("inactiveFor: %s", err)
+			}
+
+			rule.filter.InactiveFor = inactiveFor
+		}
+
+		if rule.Junk != nil {
+			if rule.Junk.Window == "" {
+				rule.Junk.Window = defaultJunkWindow
+			}
+
+			window, err := parseDuration(rule.Junk.Window)
+			if err != nil || window <= 0 {
+				return nil, invalid("junk window '%s': %s", rule.Junk.Window, errInvalidDuration)
+			}
+
+			rule.junkWindow = window
+		}
+
+		if len(rule.Allow) > 0 && !rule.SenderMismatch {
+			return nil, invalid("allow is only used with senderMismatch")
+		}
+
+		if len(rule.conditions()) == 0 {
+			return nil, invalid("no conditions, add eg. state, olderThan or inactiveFor")
+		}
+	}
+
+	return &file, nil
+}
+
+// conditions describes the conditions of the rule, other than those reported per masked email.
+func (r *policyRule) conditions() []string {
+	var conditions []string
+
+	if len(r.State) > 0 {
+		conditions = append(conditions, "state "+strings.Join(r.State, " or "))
+	}
+
+	if r.Domain != "" {
+		conditions = append(conditions, "domain "+r.Domain)
+	}
+
+	if len(r.CreatedBy) > 0 {
+		conditions = append(conditions, "created by "+strings.Join(r.CreatedBy, " or "))
+	}
+
+	if r.OlderThan != "" {
+		conditions = append(conditions, "created over "+r.OlderThan+" ago")
+	}
+
+	if r.InactiveFor != "" {
+		conditions = append(conditions, "no mail for "+r.InactiveFor)
+	}
+
+	if r.Junk != nil {
+		conditions = append(conditions, fmt.Sprintf("over %d junk messages in %s", r.Junk.Over, r.Junk.Window))
+	}
+
+	if r.SenderMismatch {
+		conditions = append(conditions, "mail from unrelated senders")
+	}
+
+	return conditions
+}
+
+// evaluatePolicy returns the action of the first rule each masked email matches. Masked emails
+// already in the state an action sets are skipped, and mail conditions are only looked up for the
+// masked emails matching the other conditions of a rule.
+func evaluatePolicy(ctx context.Context, policy *policyFile, maskedEmails []fastmail.MaskedEmail, mail policyMail) ([]policyAction, error) {
+	actions := []policyAction{}
+	acted := map[string]bool{}
+
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+
+		var candidates []fastmail.MaskedEmail
+
+		for j := range maskedEmails {
+			m := &maskedEmails[j]
+			if acted[m.ID] || m.State == fastmail.StateDeleted || (rule.Action == policyDisable && m.State == fastmail.StateDisabled) {
+				continue
+			}
+
+			if rule.filter.Match(m) {
+				candidates = append(candidates, *m)
+			}
+		}
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		reasons := map[string][]string{}
+
+		var err error
+
+		if rule.Junk != nil {
+			if candidates, err = matchJunk(ctx, rule, candidates, mail, reasons); err != nil {
+				return nil, err
+			}
+		}
+
+		if rule.SenderMismatch && len(candidates) > 0 {
+			if candidates, err = matchSenderMismatch(ctx, rule, candidates, mail, reasons); err != nil {
+				return nil, err
+			}
+		}
+
+		for j := range candidates {
+			m := &candidates[j]
+			acted[m.ID] = true
+
+			actions = append(actions, policyAction{
+				Rule:    rule.Name,
+				Action:  rule.Action,
+				ID:      m.ID,
+				Email:   m.Email,
+				Domain:  m.ForDomain,
+				State:   m.State,
+				Reasons: append(rule.filterReasons(), reasons[m.ID]...),
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+// filterReasons are the conditions of the rule matched by its filter.
+func (r *policyRule) filterReasons() []string {
+	mailConditions := 0
+	if r.Junk != nil {
+		mailConditions++
+	}
+
+	if r.SenderMismatch {
+		mailConditions++
+	}
+
+	conditions := r.conditions()
+
+	return conditions[:len(conditions)-mailConditions]
+}
+
+func matchJunk(ctx context.Context, rule *policyRule, candidates []fastmail.MaskedEmail, mail policyMail, reasons map[string][]string) ([]fastmail.MaskedEmail, error) {
+	junk, err := mail.junk(ctx, candidates, rule.junkWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := candidates[:0]
+
+	for i := range candidates {
+		if n := junk[candidates[i].ID]; n > rule.Junk.Over {
+			reasons[candidates[i].ID] = append(reasons[candidates[i].ID], fmt.Sprintf("%d junk messages in %s", n, rule.Junk.Window))
+			matched = append(matched, candidates[i])
+		}
+	}
+
+	return matched, nil
+}
+
+func matchSenderMismatch(ctx context.Context, rule *policyRule, candidates []fastmail.MaskedEmail, mail policyMail, reasons map[string][]string) ([]fastmail.MaskedEmail, error) {
+	reports, err := mail.unrelatedSenders(ctx, candidates, rule.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := candidates[:0]
+
+	for i := range candidates {
+		report, ok := reports[candidates[i].ID]
+		if !ok {
+			continue
+		}
+
+		domains := make([]string, 0, len(report.Senders))
+		for _, s := range report.Senders {
+			domains = append(domains, s.Domain)
+		}
+
+		reasons[candidates[i].ID] = append(reasons[candidates[i].ID], "mail from "+strings.Join(domains, ", "))
+		matched = append(matched, candidates[i])
+	}
+
+	return matched, nil
+}
+
+// policyActionIDs returns the masked email IDs of the actions by action.
+func policyActionIDs(actions []policyAction) map[string][]string {
+	ids := map[string][]string{}
+	for _, a := range actions {
+		ids[a.Action] = append(ids[a.Action], a.ID)
+	}
+
+	return ids
+}
+
+func applyPolicyActions(ctx context.Context, client *fastmail.Client, ids map[string][]string) error {
+	if len(ids[policyDisable]) > 0 {
+		if err := client.SetMaskedEmailsState(ctx, fastmail.StateDisabled, ids[policyDisable]...); err != nil {
+			return fmt.Errorf("failed to disable masked emails: %w", err)
+		}
+	}
+
+	if len(ids[policyDelete]) > 0 {
+		if err := client.SetMaskedEmailsState(ctx, fastmail.StateDeleted, ids[policyDelete]...); err != nil {
+			return fmt.Errorf("failed to delete masked emails: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// clientPolicyMail looks up mail conditions with the stats and leaks of masked emails.
+type clientPolicyMail struct {
+	f      *fastmask
+	client *fastmail.Client
+	now    time.Time
+}
+
+func (p *clientPolicyMail) junk(ctx context.Context, maskedEmails []fastmail.MaskedEmail, window time.Duration) (map[string]int, error) {
+	stats, err := p.f.getMailStats(ctx, maskedEmails, mailStatsOptions{
+		Window: window,
+		Limit:  defaultStatsLimit,
+		Top:    defaultStatsTop,
+		MaxAge: defaultStatsMaxAge,
+		Now:    p.now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	junk := make(map[string]int, len(stats))
+	for _, s := range stats {
+		junk[s.ID] = s.Junk
+	}
+
+	return junk, nil
+}
+
+func (p *clientPolicyMail) unrelatedSenders(ctx context.Context, maskedEmails []fastmail.MaskedEmail, allow []string) (map[string]leakReport, error) {
+	reports, err := checkLeaks(ctx, p.client, withDomain(maskedEmails), defaultLeaksLimit, allow)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]leakReport, len(reports))
+	for _, r := range reports {
+		byID[r.ID] = r
+	}
+
+	return byID, nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+// fakePolicyMail returns fixed mail conditions, recording the masked emails each is looked up for.
+type fakePolicyMail struct {
+	junkCounts  map[string]int
+	leaks       map[string]leakReport
+	junkLookups [][]string
+	leakLookups [][]string
+}
+
+func (p *fakePolicyMail) junk(_ context.Context, maskedEmails []fastmail.MaskedEmail, _ time.Duration) (map[string]int, error) {
+	p.junkLookups = append(p.junkLookups, maskedEmailIDs(maskedEmails))
+
+	return p.junkCounts, nil
+}
+
+func (p *fakePolicyMail) unrelatedSenders(_ context.Context, maskedEmails []fastmail.MaskedEmail, _ []string) (map[string]leakReport, error) {
+	p.leakLookups = append(p.leakLookups, maskedEmailIDs(maskedEmails))
+
+	return p.leaks, nil
+}
+
+func Test_Parse_Policy_File(t *testing.T) {
+	now := time.Date(2022, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	policy, err := parsePolicyFile(strings.NewReader(`
+rules:
+  - name: abandoned
+    action: delete
+    state: [pending]
+    olderThan: 1d
+  - action: disable
+    junk: {over: 5}
+`), now)
+	require.NoError(t, err)
+	require.Len(t, policy.Rules, 2)
+	require.Equal(t, now.Add(-24*time.Hour), policy.Rules[0].filter.Until)
+	require.Equal(t, "rule 2", policy.Rules[1].Name)
+	require.Equal(t, 7*24*time.Hour, policy.Rules[1].junkWindow)
+
+	for name, contents := range map[string]string{
+		"unknown field":  "rules:\n  - action: disable\n    state: [enabled]\n    unknown: true\n",
+		"unknown action": "rules:\n  - action: destroy\n    state: [enabled]\n",
+		"invalid state":  "rules:\n  - action: disable\n    state: [active]\n",
+		"invalid age":    "rules:\n  - action: disable\n    olderThan: a year\n",
+		"no conditions":  "rules:\n  - action: disable\n",
+		"allow alone":    "rules:\n  - action: disable\n    state: [enabled]\n    allow: [example.com]\n",
+	} {
+		_, err := parsePolicyFile(strings.NewReader(contents), now)
+		require.ErrorIs(t, err, errInvalidPolicy, name)
+	}
+}
+
+func Test_Evaluate_Policy(t *testing.T) {
+	now := time.Date(2022, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	policy, err := parsePolicyFile(strings.NewReader(`
+rules:
+  - name: inactive
+    action: disable
+    state: [enabled]
+    inactiveFor: 365d
+  - name: junk
+    action: disable
+    state: [enabled]
+    junk: {over: 5, window: 7d}
+  - name: abandoned
+    action: delete
+    state: [pending]
+    olderThan: 1d
+  - name: leaked
+    action: disable
+    senderMismatch: true
+`), now)
+	require.NoError(t, err)
+
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "old@fastmail.com", State: fastmail.StateEnabled, CreatedAt: "2020-01-01T00:00:00Z", LastMessageAt: "2021-01-01T00:00:00Z"},
+		{ID: "masked-2", Email: "spammed@fastmail.com", State: fastmail.StateEnabled, CreatedAt: "2022-01-01T00:00:00Z", LastMessageAt: "2022-06-29T00:00:00Z"},
+		{ID: "masked-3", Email: "pending@fastmail.com", State: fastmail.StatePending, CreatedAt: "2022-06-28T00:00:00Z"},
+		{ID: "masked-4", Email: "new@fastmail.com", State: fastmail.StatePending, CreatedAt: "2022-06-30T11:00:00Z"},
+		{ID: "masked-5", Email: "sold@fastmail.com", State: fastmail.StateEnabled, ForDomain: "shop.com", CreatedAt: "2022-01-01T00:00:00Z", LastMessageAt: "2022-06-29T00:00:00Z"},
+		{ID: "masked-6", Email: "off@fastmail.com", State: fastmail.StateDisabled, ForDomain: "shop.com", CreatedAt: "2020-01-01T00:00:00Z"},
+	}
+
+	mail := &fakePolicyMail{
+		junkCounts: map[string]int{"masked-2": 8, "masked-5": 5},
+		leaks: map[string]leakReport{
+			"masked-5": {ID: "masked-5", Senders: []leakSender{{Domain: "spam.example"}, {Domain: "other.example"}}},
+			"masked-4": {ID: "masked-4", Senders: []leakSender{{Domain: "spam.example"}}},
+		},
+	}
+
+	actions, err := evaluatePolicy(context.TODO(), policy, maskedEmails, mail)
+	require.NoError(t, err)
+	require.Equal(t, []policyAction{
+		{Rule: "inactive", Action: policyDisable, ID: "masked-1", Email: "old@fastmail.com", State: fastmail.StateEnabled, Reasons: []string{"state enabled", "no mail for 365d"}},
+		{Rule: "junk", Action: policyDisable, ID: "masked-2", Email: "spammed@fastmail.com", State: fastmail.StateEnabled, Reasons: []string{"state enabled", "8 junk messages in 7d"}},
+		{Rule: "abandoned", Action: policyDelete, ID: "masked-3", Email: "pending@fastmail.com", State: fastmail.StatePending, Reasons: []string{"state pending", "created over 1d ago"}},
+		{Rule: "leaked", Action: policyDisable, ID: "masked-4", Email: "new@fastmail.com", State: fastmail.StatePending, Reasons: []string{"mail from spam.example"}},
+		{Rule: "leaked", Action: policyDisable, ID: "masked-5", Email: "sold@fastmail.com", Domain: "shop.com", State: fastmail.StateEnabled, Reasons: []string{"mail from spam.example, other.example"}},
+	}, actions)
+
+	// Mail conditions are only looked up for masked emails not acted on and not already disabled.
+	require.Equal(t, [][]string{{"masked-2", "masked-5"}}, mail.junkLookups)
+	require.Equal(t, [][]string{{"masked-4", "masked-5"}}, mail.leakLookups)
+
+	ids := policyActionIDs(actions)
+	require.Equal(t, []string{"masked-1", "masked-2", "masked-4", "masked-5"}, ids[policyDisable])
+	require.Equal(t, []string{"masked-3"}, ids[policyDelete])
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagProfile    = "profile"
+	defaultProfile = "default"
+)
+
+var (
+	errProfileNotFound    = errors.New("profile not found")
+	errInvalidProfileName = errors.New("invalid profile name, use letters, numbers, '-' and '_'")
+
+	profileNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+// profileKey returns the config key for a setting of the named profile.
+func profileKey(profile, key string) string {
+	return configKeyProfiles + "." + profile + "." + key
+}
+
+// validateProfileName checks the name can be used as a config key, names are case-insensitive.
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: '%s'", errInvalidProfileName, name)
+	}
+
+	return nil
+}
+
+// selectedProfile returns the profile to use, in order of precedence: the --profile flag,
+// FASTMASK_PROFILE, the 'profile' config setting, and lastly the default profile.
+func (f *fastmask) selectedProfile(v *viper.Viper) string {
+	if profile := f.lookupSetting(v, flagProfile, configKeyProfile); profile != "" {
+		return strings.ToLower(profile)
+	}
+
+	return defaultProfile
+}
+
+// profileNames returns the sorted names of the profiles in the config file.
+func (c *config) profileNames() []string {
+	profiles := c.v.GetStringMap(configKeyProfiles)
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *config) profileExists(name string) bool {
+	return c.v.IsSet(configKeyProfiles + "." + name)
+}
+
+func (f *fastmask) loadProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles.",
+		Long:  "Manage named profiles for multiple Fastmail accounts. Use 'fastmask login --profile <name>' to add a profile.",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List profiles.",
+		Args:  cobra.NoArgs,
+		RunE:  f.runProfileList,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the active profile.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  f.runProfileUse,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a profile and its stored credentials.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  f.runProfileRemove,
+	})
+
+	return cmd
+}
+
+func (f *fastmask) runProfileList(_ *cobra.Command, _ []string) error {
+	names := f.config.profileNames()
+
+	if len(names) == 0 {
+		fmt.Println("No profiles found. Please run 'fastmask login'.")
+
+		return nil
+	}
+
+	for _, name := range names {
+		marker := " "
+		if name == f.config.profile {
+			marker = "*"
+		}
+
+		fmt.Printf("%s %s\t%s\n", marker, name, f.config.v.GetString(profileKey(name, configKeyUsername)))
+	}
+
+	return nil
+}
+
+func (f *fastmask) runProfileUse(_ *cobra.Command, args []string) error {
+	name := strings.ToLower(args[0])
+
+	if !f.config.profileExists(name) {
+		return fmt.Errorf("%w: '%s', please run 'fastmask login --profile %s'", errProfileNotFound, name, name)
+	}
+
+	f.config.v.Set(configKeyProfile, name)
+
+	if err := f.config.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("🟢 Using profile '%s'.\n", name)
+
+	return nil
+}
+
+func (f *fastmask) runProfileRemove(cmd *cobra.Command, args []string) error {
+	name := strings.ToLower(args[0])
+
+	if !f.config.profileExists(name) {
+		return fmt.Errorf("%w: '%s'", errProfileNotFound, name)
+	}
+
+	ok, err := confirm(cmd, fmt.Sprintf("Confirm removal of profile '%s' and its credentials", name))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	store, err := f.config.credentialStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(name); err != nil {
+		return err
+	}
+
+	f.config.unset(configKeyProfiles + "." + name)
+
+	if f.config.v.GetString(configKeyProfile) == name {
+		f.config.unset(configKeyProfile)
+	}
+
+	if err := f.config.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile '%s' removed.\n", name)
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// confirm asks the user to confirm with y/N, returns true without asking when --no-confirm is set.
+func confirm(cmd *cobra.Command, message string) (bool, error) {
+	skipConfirm, err := cmd.Flags().GetBool(flagNoConfirm)
+	if err != nil {
+		return false, fmt.Errorf("failed to get flag %s: %w", flagNoConfirm, err)
+	}
+
+	if skipConfirm {
+		return true, nil
+	}
+
+	fmt.Printf("%s: (y/N): ", message)
+
+	var answer string
+
+	fmt.Scanln(&answer)
+
+	s := strings.ToLower(answer)
+
+	return s == "y" || s == "yes", nil
+}
@@ -0,0 +1,345 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagListen = "listen"
+	flagURL    = "url"
+	flagExec   = "exec"
+	flagOn     = "on"
+
+	defaultPushListen = ":8080"
+
+	pushPathPrefix      = "/push/"
+	pushSecretBytes     = 16
+	pushQueueSize       = 16
+	maxPushSize         = 1 << 20
+	pushReadTimeout     = 10 * time.Second
+	pushShutdownTimeout = 5 * time.Second
+)
+
+var (
+	errPushSubscriptionMismatch = errors.New("push verification for another subscription")
+
+	pushChangeTypes = []string{fastmail.ChangeCreated, fastmail.ChangeUpdated, fastmail.ChangeDestroyed}
+)
+
+// pushReceiver receives the pushes of a PushSubscription, verifying it and writing the masked
+// email changes it is notified of.
+type pushReceiver struct {
+	client  *fastmail.Client
+	watcher *maskedEmailWatcher
+	// path is the secret URL path pushes are accepted on, so only the server can push.
+	path           string
+	subscriptionID string
+	messages       chan *fastmail.PushMessage
+}
+
+func (f *fastmask) loadPushReceiverCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "push-receiver --url <public url>",
+		Short: "Receive masked email changes pushed by Fastmail.",
+		Long: "Listen for pushes from Fastmail and print each masked email change as a line of JSON, running " +
+			"the --exec commands for each change. Fastmail must be able to reach the listen address at --url, " +
+			"eg. through a reverse proxy. The push subscription is verified when Fastmail first pushes, and " +
+			"removed on exit.\n\n" +
+			"Commands are run with a shell, with the change as JSON on stdin and in the FASTMASK_CHANGE, " +
+			"FASTMASK_ID, FASTMASK_EMAIL and FASTMASK_STATE environment variables.",
+		Example: "  fastmask push-receiver --listen :8080 --url https://fastmask.example.com\n" +
+			"  fastmask push-receiver --url https://fastmask.example.com --on created --exec 'notify-send \"New mask $FASTMASK_EMAIL\"'",
+		Args: cobra.NoArgs,
+		RunE: f.runPushReceiver,
+	}
+
+	cmd.Flags().String(flagListen, defaultPushListen, "Address to listen for pushes on.")
+	cmd.Flags().String(flagURL, "", "Public URL Fastmail pushes to, forwarded to the listen address.")
+	cmd.Flags().StringArray(flagExec, nil, "Command to run for each change, can be repeated.")
+	cmd.Flags().StringSlice(flagOn, pushChangeTypes, "Changes to run commands for: "+strings.Join(pushChangeTypes, ", ")+".")
+	// nolint:errcheck // flag exists.
+	cmd.MarkFlagRequired(flagURL)
+
+	return cmd
+}
+
+func (f *fastmask) runPushReceiver(cmd *cobra.Command, _ []string) error {
+	listen, err := cmd.Flags().GetString(flagListen)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagListen, err)
+	}
+
+	publicURL, err := cmd.Flags().GetString(flagURL)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagURL, err)
+	}
+
+	commands, err := cmd.Flags().GetStringArray(flagExec)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagExec, err)
+	}
+
+	on, err := cmd.Flags().GetStringSlice(flagOn)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagOn, err)
+	}
+
+	for _, changeType := range on {
+		if err := oneOf(pushChangeTypes...)(changeType); err != nil {
+			return fmt.Errorf("invalid --%s: %w", flagOn, err)
+		}
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p, err := newPushReceiver(client, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	p.watcher.onChange = execChangeCommands(commands, on)
+
+	if err := p.watcher.load(ctx); err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+
+	server := &http.Server{Handler: p, ReadHeaderTimeout: pushReadTimeout}
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "🛑 Stopped listening on %s: %s\n", listen, err)
+			stop()
+		}
+	}()
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), pushShutdownTimeout)
+		defer cancel()
+
+		// nolint:errcheck // ignore error, we are exiting.
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := p.subscribe(ctx, strings.TrimSuffix(publicURL, "/")+p.path); err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to create push subscription: %w", err)
+	}
+
+	defer func() {
+		// ctx is done, so use a new one to remove the subscription.
+		unsubscribeCtx, cancel := context.WithTimeout(context.Background(), pushShutdownTimeout)
+		defer cancel()
+
+		if err := client.DestroyPushSubscription(unsubscribeCtx, p.subscriptionID); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to remove push subscription %s: %s\n", p.subscriptionID, err)
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "📬 Listening for pushes on %s, press Ctrl+C to stop.\n", listen)
+
+	return p.run(ctx)
+}
+
+func newPushReceiver(client *fastmail.Client, out io.Writer) (*pushReceiver, error) {
+	secret := make([]byte, pushSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate push url: %w", err)
+	}
+
+	return &pushReceiver{
+		client:   client,
+		watcher:  newMaskedEmailWatcher(client, out),
+		path:     pushPathPrefix + hex.EncodeToString(secret),
+		messages: make(chan *fastmail.PushMessage, pushQueueSize),
+	}, nil
+}
+
+// subscribe creates the push subscription for url, which the server then sends a PushVerification to.
+func (p *pushReceiver) subscribe(ctx context.Context, url string) error {
+	hostname, _ := os.Hostname()
+
+	sub, err := p.client.CreatePushSubscription(ctx, appName+"-"+hostname, url, fastmail.TypeMaskedEmail)
+	if err != nil {
+		// nolint:wrapcheck // callers wrap errors.
+		return err
+	}
+
+	p.subscriptionID = sub.ID
+
+	return nil
+}
+
+// ServeHTTP accepts pushes on the secret path, queueing them to be handled by run.
+func (p *pushReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != p.path {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	msg, err := fastmail.DecodePushMessage(http.MaxBytesReader(w, r.Body, maxPushSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	select {
+	case p.messages <- msg:
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "too many pushes", http.StatusServiceUnavailable)
+	}
+}
+
+// run handles queued pushes one at a time until ctx is done.
+func (p *pushReceiver) run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-p.messages:
+			if err := p.handle(ctx, msg); err != nil {
+				if reauthNeeded(err) {
+					fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+					return nil
+				}
+
+				fmt.Fprintf(os.Stderr, "⚠️  Failed to handle push: %s\n", err)
+			}
+		}
+	}
+}
+
+func (p *pushReceiver) handle(ctx context.Context, msg *fastmail.PushMessage) error {
+	if v := msg.Verification; v != nil {
+		if v.PushSubscriptionID != p.subscriptionID {
+			return fmt.Errorf("%w: '%s'", errPushSubscriptionMismatch, v.PushSubscriptionID)
+		}
+
+		if err := p.client.VerifyPushSubscription(ctx, v.PushSubscriptionID, v.VerificationCode); err != nil {
+			// nolint:wrapcheck // callers wrap errors.
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, "✅ Push subscription verified.")
+
+		return nil
+	}
+
+	state, ok := msg.StateChange.State(p.client.AccountID(), fastmail.TypeMaskedEmail)
+	if !ok || state == p.watcher.state {
+		return nil
+	}
+
+	return p.watcher.sync(ctx)
+}
+
+// execChangeCommands returns an onChange func running the commands for the given change types.
+// Commands that fail are reported, without stopping.
+func execChangeCommands(commands, changeTypes []string) func(ctx context.Context, e *watchEvent) {
+	return func(ctx context.Context, e *watchEvent) {
+		if len(commands) == 0 || !containsString(changeTypes, e.Type) {
+			return
+		}
+
+		input, err := json.Marshal(e)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to encode change: %s\n", err)
+
+			return
+		}
+
+		state := ""
+		if m := e.New; m != nil {
+			state = m.State
+		}
+
+		for _, command := range commands {
+			c := shellCommand(ctx, command)
+			c.Stdin = strings.NewReader(string(input))
+			c.Stdout = os.Stderr
+			c.Stderr = os.Stderr
+			c.Env = append(os.Environ(),
+				"FASTMASK_CHANGE="+e.Type,
+				"FASTMASK_ID="+e.ID,
+				"FASTMASK_EMAIL="+e.Email,
+				"FASTMASK_STATE="+state,
+			)
+
+			if err := c.Run(); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Command '%s' failed for %s: %s\n", command, e.Email, err)
+			}
+		}
+	}
+}
+
+// shellCommand returns command run by the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		// nolint:gosec // running the user's command is the point.
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+
+	// nolint:gosec // running the user's command is the point.
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Push_Receiver(t *testing.T) {
+	api := &fakeMaskedEmailAPI{
+		state: "1",
+		maskedEmails: map[string]fastmail.MaskedEmail{
+			"masked-1": {ID: "masked-1", Email: "one@fastmail.com", State: fastmail.StateEnabled},
+		},
+	}
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	apiEndpoint := fastmail.APIEndpoint
+	fastmail.APIEndpoint = server.URL
+
+	defer func() { fastmail.APIEndpoint = apiEndpoint }()
+
+	var out bytes.Buffer
+
+	p, err := newPushReceiver(fastmail.NewClient("fastmask").SetTokenAuthCredentials("account-1", "token"), &out)
+	require.NoError(t, err)
+
+	var changes []string
+
+	p.watcher.onChange = func(_ context.Context, e *watchEvent) {
+		changes = append(changes, e.Type+" "+e.Email)
+	}
+
+	ctx := context.TODO()
+
+	require.NoError(t, p.watcher.load(ctx))
+	require.NoError(t, p.subscribe(ctx, "https://fastmask.example.com"+p.path))
+	require.Equal(t, "push-1", p.subscriptionID)
+	require.Equal(t, "https://fastmask.example.com"+p.path, api.pushSets[0]["create"].(map[string]interface{})["fastmask"].(map[string]interface{})["url"])
+
+	receiver := httptest.NewServer(p)
+	defer receiver.Close()
+
+	push := func(path, body string) int {
+		res, err := http.Post(receiver.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+
+		defer res.Body.Close()
+
+		return res.StatusCode
+	}
+
+	// Pushes are only accepted on the secret path.
+	require.Equal(t, http.StatusNotFound, push("/push/guess", `{"@type": "StateChange", "changed": {}}`))
+	require.Equal(t, http.StatusBadRequest, push(p.path, `{"@type": "Unknown"}`))
+
+	require.Equal(t, http.StatusAccepted, push(p.path, `{"@type": "PushVerification", "pushSubscriptionId": "push-1", "verificationCode": "code-1"}`))
+	require.NoError(t, p.handle(ctx, <-p.messages))
+	require.Equal(t, map[string]interface{}{"push-1": map[string]interface{}{"verificationCode": "code-1"}}, api.pushSets[1]["update"])
+
+	require.Equal(t, http.StatusAccepted, push(p.path, `{"@type": "PushVerification", "pushSubscriptionId": "push-2", "verificationCode": "code-2"}`))
+	require.ErrorIs(t, p.handle(ctx, <-p.messages), errPushSubscriptionMismatch)
+
+	api.state = "2"
+	api.maskedEmails["masked-2"] = fastmail.MaskedEmail{ID: "masked-2", Email: "two@fastmail.com", State: fastmail.StateEnabled}
+	api.changes = map[string]interface{}{"accountId": "account-1", "oldState": "1", "newState": "2", "created": []string{"masked-2"}}
+
+	require.Equal(t, http.StatusAccepted, push(p.path, `{"@type": "StateChange", "changed": {"account-1": {"MaskedEmail": "2"}}}`))
+
+	require.NoError(t, p.handle(ctx, <-p.messages))
+
+	// A push for a state already seen is ignored.
+	require.NoError(t, p.handle(ctx, &fastmail.PushMessage{StateChange: &fastmail.StateChange{
+		Changed: map[string]map[string]string{"account-1": {fastmail.TypeMaskedEmail: "2"}},
+	}}))
+
+	require.Equal(t, []string{"created two@fastmail.com"}, changes)
+	require.Contains(t, out.String(), `"type":"created","id":"masked-2"`)
+}
+
+func Test_Exec_Change_Commands(t *testing.T) {
+	dir := t.TempDir()
+
+	onChange := execChangeCommands([]string{`cat > "` + dir + `/$FASTMASK_ID.json"; echo "$FASTMASK_CHANGE $FASTMASK_EMAIL $FASTMASK_STATE" > "` + dir + `/$FASTMASK_ID.env"`},
+		[]string{fastmail.ChangeCreated})
+
+	onChange(context.TODO(), &watchEvent{MaskedEmailChange: fastmail.MaskedEmailChange{
+		Type: fastmail.ChangeCreated, ID: "masked-1", Email: "one@fastmail.com", New: &fastmail.MaskedEmail{State: fastmail.StatePending},
+	}})
+	onChange(context.TODO(), &watchEvent{MaskedEmailChange: fastmail.MaskedEmailChange{Type: fastmail.ChangeDestroyed, ID: "masked-2"}})
+
+	env, err := os.ReadFile(dir + "/masked-1.env")
+	require.NoError(t, err)
+	require.Equal(t, "created one@fastmail.com pending\n", string(env))
+
+	input, err := os.ReadFile(dir + "/masked-1.json")
+	require.NoError(t, err)
+	require.Contains(t, string(input), `"email":"one@fastmail.com"`)
+
+	_, err = os.Stat(dir + "/masked-2.env")
+	require.True(t, os.IsNotExist(err), "destroyed changes do not run commands")
+}
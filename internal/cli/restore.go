@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func (f *fastmask) loadRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore [id|email]...",
+		Short: "Restore deleted masked emails.",
+		Long: "Restore soft deleted masked emails by ID or email, or by selector flags, setting them " +
+			"back to enabled. Only deleted masked emails are restored, and they are shown before confirming.",
+		Example: "  fastmask restore masked-12345678\n  fastmask restore --domain '*.example.com' --dry-run",
+		RunE:    f.runRestore,
+	}
+
+	addFilterFlags(cmd)
+	cmd.Flags().Bool(flagDryRun, false, "Show the masked emails that would be restored without restoring them.")
+
+	return cmd
+}
+
+func (f *fastmask) runRestore(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && !filterFlagsChanged(cmd) {
+		return errNoSelection
+	}
+
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagDryRun, err)
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := client.GetMaskedEmails(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	selected, err := selectMaskedEmails(cmd, maskedEmails, args)
+	if err != nil {
+		return err
+	}
+
+	// Only soft deleted masked emails are restored, disabled and pending ones are left as they are.
+	selected = keepState(selected, fastmail.StateDeleted)
+
+	if len(selected) == 0 {
+		fmt.Println("No deleted masked emails matched.")
+
+		return nil
+	}
+
+	if err := writeMaskedEmailTable(os.Stdout, selected); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run, %d masked emails would be restored.\n", len(selected))
+
+		return nil
+	}
+
+	ok, err := confirm(cmd, fmt.Sprintf("\nConfirm %d masked emails will be restored", len(selected)))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOperationCancelled
+	}
+
+	ids := make([]string, 0, len(selected))
+	for i := range selected {
+		ids = append(ids, selected[i].ID)
+	}
+
+	if err := client.SetMaskedEmailsState(cmd.Context(), fastmail.StateEnabled, ids...); err != nil {
+		return fmt.Errorf("failed to restore masked emails: %w", err)
+	}
+
+	fmt.Printf("%d masked emails restored.\n", len(ids))
+
+	return nil
+}
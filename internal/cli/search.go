@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagLimit = "limit"
+
+	defaultSearchLimit = 20
+
+	ansiHighlight = "\x1b[1;33m"
+	ansiReset     = "\x1b[0m"
+)
+
+func (f *fastmask) loadSearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search masked emails.",
+		Long: "Search masked emails by domain, description, email and url. Matching tolerates typos and " +
+			"results are ranked best match first, with matches highlighted in table output.",
+		Example: "  fastmask search endless newsleter\n  fastmask search shop -o table",
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    f.runSearch,
+	}
+
+	cmd.Flags().Int(flagLimit, defaultSearchLimit, "Maximum number of results, 0 for no limit.")
+	addCacheFlags(cmd)
+
+	return cmd
+}
+
+func (f *fastmask) runSearch(cmd *cobra.Command, args []string) error {
+	limit, err := cmd.Flags().GetInt(flagLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagLimit, err)
+	}
+
+	maskedEmails, err := f.getCachedMaskedEmails(cmd)
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	results := fastmail.SearchMaskedEmails(maskedEmails, strings.Join(args, " "))
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "No masked emails found.")
+	}
+
+	if f.config.output == outputTable {
+		return writeSearchTable(os.Stdout, results, useColor(os.Stdout))
+	}
+
+	return f.writeOutput(results)
+}
+
+// useColor reports whether ANSI colors should be written to out.
+func useColor(out *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	return term.IsTerminal(int(out.Fd()))
+}
+
+// writeSearchTable writes the results as a table, highlighting matched spans when color is true.
+// tabwriter counts escape sequences towards the column width, so columns are padded here.
+func writeSearchTable(out io.Writer, results []fastmail.SearchResult, color bool) error {
+	header := []string{"ID", "EMAIL", "STATE", "DOMAIN", "DESCRIPTION", "URL"}
+	rows := make([][]string, 0, len(results))
+	widths := make([]int, len(header))
+
+	for i, h := range header {
+		widths[i] = len(h)
+	}
+
+	for i := range results {
+		m := &results[i].MaskedEmail
+		rows = append(rows, []string{m.ID, m.Email, m.State, m.ForDomain, tableCell(m.Description), m.URL})
+
+		for j, cell := range rows[len(rows)-1] {
+			if n := utf8.RuneCountInString(cell); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	writeRow := func(cells []string, matches map[string][]fastmail.Span) error {
+		fields := []string{"", fastmail.SearchFieldEmail, "", fastmail.SearchFieldDomain, fastmail.SearchFieldDescription, fastmail.SearchFieldURL}
+
+		var b strings.Builder
+
+		for i, cell := range cells {
+			text := cell
+			if color && fields[i] != "" {
+				text = highlight(cell, matches[fields[i]])
+			}
+
+			b.WriteString(text)
+
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2)) // nolint:gomnd // column padding.
+			}
+		}
+
+		_, err := fmt.Fprintln(out, strings.TrimRight(b.String(), " "))
+
+		// nolint:wrapcheck // ignore error, we are writing to stdout
+		return err
+	}
+
+	if err := writeRow(header, nil); err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		if err := writeRow(row, results[i].Matches); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// highlight wraps the spans of s in ANSI highlight escape sequences.
+func highlight(s string, spans []fastmail.Span) string {
+	var b strings.Builder
+
+	last := 0
+
+	for _, span := range spans {
+		if span.Start < last || span.End > len(s) {
+			continue
+		}
+
+		b.WriteString(s[last:span.Start])
+		b.WriteString(ansiHighlight)
+		b.WriteString(s[span.Start:span.End])
+		b.WriteString(ansiReset)
+
+		last = span.End
+	}
+
+	b.WriteString(s[last:])
+
+	return b.String()
+}
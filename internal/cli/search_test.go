@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Search_Table(t *testing.T) {
+	results := fastmail.SearchMaskedEmails([]fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "test.example1234@fastmail.com", ForDomain: "example.com", Description: "endless newsletters"},
+	}, "newsletter")
+	require.Len(t, results, 1)
+
+	t.Run("Highlight", func(t *testing.T) {
+		require.Equal(t, "endless "+ansiHighlight+"newsletter"+ansiReset+"s",
+			highlight("endless newsletters", results[0].Matches[fastmail.SearchFieldDescription]))
+	})
+
+	t.Run("Columns Aligned With Color", func(t *testing.T) {
+		var plain, colored bytes.Buffer
+
+		require.NoError(t, writeSearchTable(&plain, results, false))
+		require.NoError(t, writeSearchTable(&colored, results, true))
+
+		stripped := strings.NewReplacer(ansiHighlight, "", ansiReset, "")
+		require.Equal(t, plain.String(), stripped.Replace(colored.String()))
+	})
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	configKeyOutput             = "output"
+	configKeyDefaultDescription = "default_description"
+
+	secretMask = "********"
+)
+
+var (
+	errUnknownSetting = errors.New("unknown setting")
+	errInvalidSetting = errors.New("invalid setting value")
+)
+
+// setting is a config key that can be changed with 'fastmask config set'.
+type setting struct {
+	key         string
+	description string
+	validate    func(value string) error
+}
+
+var knownSettings = []setting{
+	{
+		key:         configKeyProfile,
+		description: "Active profile.",
+		validate:    validateProfileName,
+	},
+	{
+		key:         configKeyCredentialStore,
+		description: "Where credentials are stored: auto, keyring or file.",
+		validate:    oneOf(credentialStoreAuto, credentialStoreKeyring, credentialStoreFile),
+	},
+	{
+		key:         configKeyOutput,
+		description: "Output format: json or table.",
+		validate:    oneOf(outputFormats...),
+	},
+	{
+		key:         configKeyDefaultDescription,
+		description: "Description used when creating a masked email without one, '{domain}' and '{date}' are replaced.",
+	},
+}
+
+func findSetting(key string) (setting, error) {
+	for _, s := range knownSettings {
+		if s.key == key {
+			return s, nil
+		}
+	}
+
+	return setting{}, fmt.Errorf("%w: '%s'", errUnknownSetting, key)
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%w: '%s', expected one of: %s", errInvalidSetting, value, strings.Join(values, ", "))
+	}
+}
+
+// isSecretKey reports whether the value of key should be masked when displayed.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, s := range []string{"token", "secret", "password", "passphrase"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// displayValue returns value formatted for display, masking secrets.
+func displayValue(key string, value interface{}) string {
+	if isSecretKey(key) {
+		return secretMask
+	}
+
+	return fmt.Sprint(value)
+}
+
+// expandDescription replaces the placeholders in the default description template.
+func expandDescription(template, domain string, now time.Time) string {
+	return strings.NewReplacer(
+		"{domain}", domain,
+		"{date}", now.Format("2006-01-02"),
+	).Replace(template)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Settings(t *testing.T) {
+	t.Run("Validate Known Settings", func(t *testing.T) {
+		s, err := findSetting(configKeyOutput)
+		require.NoError(t, err)
+		require.NoError(t, s.validate(outputTable))
+		require.ErrorIs(t, s.validate("xml"), errInvalidSetting)
+
+		_, err = findSetting("unknown")
+		require.ErrorIs(t, err, errUnknownSetting)
+	})
+
+	t.Run("Mask Secrets", func(t *testing.T) {
+		require.Equal(t, secretMask, displayValue(configKeyAccessToken, "fmb1-secret"))
+		require.Equal(t, secretMask, displayValue("profiles.work.access_token", "fmb1-secret"))
+		require.Equal(t, "table", displayValue(configKeyOutput, "table"))
+	})
+
+	t.Run("Flatten Settings", func(t *testing.T) {
+		out := map[string]interface{}{}
+		flattenSettings("", map[string]interface{}{
+			"output": "json",
+			"profiles": map[string]interface{}{
+				"work": map[string]interface{}{"username": "me@example.com"},
+			},
+		}, out)
+
+		require.Equal(t, map[string]interface{}{
+			"output":                 "json",
+			"profiles.work.username": "me@example.com",
+		}, out)
+	})
+
+	t.Run("Expand Description", func(t *testing.T) {
+		now := time.Date(2022, 4, 20, 12, 0, 0, 0, time.UTC)
+
+		require.Equal(t, "example.com signup 2022-04-20", expandDescription("{domain} signup {date}", "example.com", now))
+	})
+}
+
+func Test_Output_Setting(t *testing.T) {
+	f := &fastmask{cmd: &cobra.Command{}}
+	f.cmd.PersistentFlags().StringP(flagOutput, "o", "", "")
+
+	v := viper.New()
+
+	output, err := f.outputSetting(v)
+	require.NoError(t, err)
+	require.Equal(t, outputJSON, output)
+
+	// An invalid environment variable falls back to the default.
+	t.Setenv(envName(configKeyOutput), "xml")
+
+	output, err = f.outputSetting(v)
+	require.NoError(t, err)
+	require.Equal(t, outputJSON, output)
+
+	require.NoError(t, f.cmd.PersistentFlags().Parse([]string{"-o", "table"}))
+
+	output, err = f.outputSetting(v)
+	require.NoError(t, err)
+	require.Equal(t, outputTable, output)
+
+	require.NoError(t, f.cmd.PersistentFlags().Parse([]string{"-o", "xml"}))
+
+	_, err = f.outputSetting(v)
+	require.ErrorIs(t, err, errInvalidSetting)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+var errMaskedEmailNotFound = errors.New("no masked email found")
+
+func (f *fastmask) loadShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <id|email>",
+		Short: "Show a masked email.",
+		Long: "Show the masked email with the given ID or email address, use it to find which site an address was created for.\n\n" +
+			"A partial ID or address shows every masked email containing it.",
+		Example: "  fastmask show foo.bar123@fastmail.com\n  fastmask show foo.bar",
+		Args:    cobra.ExactArgs(1),
+		RunE:    f.runShow,
+	}
+
+	addCacheFlags(cmd)
+
+	return cmd
+}
+
+func (f *fastmask) runShow(cmd *cobra.Command, args []string) error {
+	maskedEmails, err := f.getCachedMaskedEmails(cmd)
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	matches := findMaskedEmails(maskedEmails, args[0])
+
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%w matching '%s'", errMaskedEmailNotFound, args[0])
+	case 1:
+		return f.writeOutput(&matches[0])
+	default:
+		fmt.Fprintf(os.Stderr, "Found %d masked emails matching '%s'.\n", len(matches), args[0])
+
+		return f.writeOutput(matches)
+	}
+}
+
+// findMaskedEmails returns the masked email whose ID or email equals query, otherwise every
+// masked email with an ID or email containing query. Matching is case-insensitive.
+func findMaskedEmails(maskedEmails []fastmail.MaskedEmail, query string) []fastmail.MaskedEmail {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var partial []fastmail.MaskedEmail
+
+	for i := range maskedEmails {
+		id, email := strings.ToLower(maskedEmails[i].ID), strings.ToLower(maskedEmails[i].Email)
+
+		if id == query || email == query {
+			return []fastmail.MaskedEmail{maskedEmails[i]}
+		}
+
+		if strings.Contains(id, query) || strings.Contains(email, query) {
+			partial = append(partial, maskedEmails[i])
+		}
+	}
+
+	return partial
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Find_Masked_Emails(t *testing.T) {
+	maskedEmails := []fastmail.MaskedEmail{
+		{ID: "masked-1", Email: "foo.bar123@fastmail.com"},
+		{ID: "masked-2", Email: "foo.bar1234@fastmail.com"},
+		{ID: "masked-3", Email: "other.thing@fastmail.com"},
+	}
+
+	require.Equal(t, maskedEmails[:1], findMaskedEmails(maskedEmails, "Foo.Bar123@fastmail.com"), "exact email match")
+	require.Equal(t, maskedEmails[2:], findMaskedEmails(maskedEmails, "masked-3"), "exact id match")
+	require.Equal(t, maskedEmails[:2], findMaskedEmails(maskedEmails, "foo.bar"), "partial match")
+	require.Empty(t, findMaskedEmails(maskedEmails, "nothing"))
+}
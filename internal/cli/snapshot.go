@@ -0,0 +1,336 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	snapshotsDirname    = "snapshots"
+	snapshotExt         = ".json.gz"
+	snapshotTimeFormat  = "20060102T150405Z"
+	snapshotPermissions = 0o600
+	snapshotLive        = "live"
+)
+
+var (
+	errSnapshotNotFound        = errors.New("snapshot not found")
+	errSnapshotAmbiguous       = errors.New("snapshot name matches more than one snapshot")
+	errNoSnapshots             = errors.New("no snapshots, run 'fastmask snapshot' first")
+	errSnapshotAccountMismatch = errors.New("snapshots are of different accounts")
+	errSnapshotExists          = errors.New("snapshot already exists, wait a second and try again")
+)
+
+// snapshot is a copy of all masked emails at a point in time.
+type snapshot struct {
+	TakenAt      time.Time              `json:"takenAt"`
+	AccountID    string                 `json:"accountId"`
+	State        string                 `json:"state"`
+	MaskedEmails []fastmail.MaskedEmail `json:"maskedEmails"`
+}
+
+func (f *fastmask) loadSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save a snapshot of all masked emails.",
+		Long: "Save a timestamped, compressed copy of all masked emails, to compare with 'fastmask diff' " +
+			"later. Snapshots are stored per profile in the state directory.",
+		Example: "  fastmask snapshot\n  fastmask snapshot list",
+		Args:    cobra.NoArgs,
+		RunE:    f.runSnapshot,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List saved snapshots.",
+		Args:  cobra.NoArgs,
+		RunE:  f.runSnapshotList,
+	})
+
+	return cmd
+}
+
+func (f *fastmask) loadDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff [snapshot] [snapshot|live]",
+		Short: "Show what changed between snapshots.",
+		Long: "Show the masked emails created, destroyed, or with a changed state, domain, description or url " +
+			"between two snapshots, or a snapshot and the live masked emails. Without arguments the latest " +
+			"snapshot is compared to live, with one argument that snapshot is. Snapshots are named by a " +
+			"unique prefix of their name, see 'fastmask snapshot list', or by file path.",
+		Example: "  fastmask diff\n  fastmask diff 20220501 -o table\n  fastmask diff 20220501T120000Z 20220601T120000Z",
+		Args:    cobra.MaximumNArgs(2), // nolint:gomnd // two snapshots.
+		RunE:    f.runDiff,
+	}
+}
+
+// snapshotsDir returns the directory of the active profile's snapshots.
+func (f *fastmask) snapshotsDir() string {
+	return path.Join(f.config.dirs.state, snapshotsDirname, f.config.profile)
+}
+
+func (f *fastmask) runSnapshot(cmd *cobra.Command, _ []string) error {
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	list, err := client.GetMaskedEmailList(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	s := &snapshot{
+		TakenAt:      time.Now().UTC().Truncate(time.Second),
+		AccountID:    list.AccountID,
+		State:        list.State,
+		MaskedEmails: list.List,
+	}
+
+	filename, err := s.write(f.snapshotsDir())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Snapshot %s saved with %d masked emails.\n", snapshotName(filename), len(s.MaskedEmails))
+
+	return nil
+}
+
+func (f *fastmask) runSnapshotList(_ *cobra.Command, _ []string) error {
+	names, err := listSnapshots(f.snapshotsDir())
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No snapshots.")
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tMASKED EMAILS")
+
+	for _, name := range names {
+		s, err := readSnapshot(path.Join(f.snapshotsDir(), name+snapshotExt))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\t%d\n", name, len(s.MaskedEmails))
+	}
+
+	// nolint:wrapcheck // ignore error, we are writing to stdout
+	return w.Flush()
+}
+
+func (f *fastmask) runDiff(cmd *cobra.Command, args []string) error {
+	refs := append([]string{}, args...)
+
+	if len(refs) == 0 {
+		names, err := listSnapshots(f.snapshotsDir())
+		if err != nil {
+			return err
+		}
+
+		if len(names) == 0 {
+			return errNoSnapshots
+		}
+
+		refs = append(refs, names[len(names)-1])
+	}
+
+	if len(refs) == 1 {
+		refs = append(refs, snapshotLive)
+	}
+
+	snapshots := make([]*snapshot, len(refs))
+
+	for i, ref := range refs {
+		if ref != snapshotLive {
+			s, err := f.findSnapshot(ref)
+			if err != nil {
+				return err
+			}
+
+			snapshots[i] = s
+
+			continue
+		}
+
+		client, err := f.newClient()
+		if err != nil {
+			return err
+		}
+
+		list, err := client.GetMaskedEmailList(cmd.Context())
+		if err != nil {
+			if reauthNeeded(err) {
+				fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+				return nil
+			}
+
+			return fmt.Errorf("failed to get masked emails: %w", err)
+		}
+
+		snapshots[i] = &snapshot{AccountID: list.AccountID, State: list.State, MaskedEmails: list.List}
+	}
+
+	if err := sameAccount(refs, snapshots); err != nil {
+		return err
+	}
+
+	return f.writeOutput(fastmail.DiffMaskedEmails(snapshots[0].MaskedEmails, snapshots[1].MaskedEmails))
+}
+
+// sameAccount returns an error if the snapshots are of different accounts, as comparing them
+// would show every masked email as created or destroyed.
+func sameAccount(refs []string, snapshots []*snapshot) error {
+	for i := 1; i < len(snapshots); i++ {
+		a, b := snapshots[0].AccountID, snapshots[i].AccountID
+		if a != "" && b != "" && a != b {
+			return fmt.Errorf("%w: %s is account %s, %s is account %s", errSnapshotAccountMismatch, refs[0], a, refs[i], b)
+		}
+	}
+
+	return nil
+}
+
+// findSnapshot reads the snapshot at a file path, or with a name starting with ref.
+func (f *fastmask) findSnapshot(ref string) (*snapshot, error) {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return readSnapshot(ref)
+	}
+
+	names, err := listSnapshots(f.snapshotsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	ref = strings.TrimSuffix(ref, snapshotExt)
+
+	var matches []string
+
+	for _, name := range names {
+		if name == ref {
+			matches = []string{name}
+
+			break
+		}
+
+		if strings.HasPrefix(name, ref) {
+			matches = append(matches, name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: '%s'", errSnapshotNotFound, ref)
+	case 1:
+		return readSnapshot(path.Join(f.snapshotsDir(), matches[0]+snapshotExt))
+	default:
+		return nil, fmt.Errorf("%w: '%s' matches %s", errSnapshotAmbiguous, ref, strings.Join(matches, ", "))
+	}
+}
+
+// write saves the snapshot in dir, named by when it was taken, returning the file name.
+func (s *snapshot) write(dir string) (string, error) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+
+	if err := json.NewEncoder(gz).Encode(s); err != nil {
+		return "", fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	filename := path.Join(dir, s.TakenAt.UTC().Format(snapshotTimeFormat)+snapshotExt)
+
+	// Names have second precision, a snapshot taken in the same second is not replaced.
+	if _, err := os.Stat(filename); err == nil {
+		return "", fmt.Errorf("%w: %s", errSnapshotExists, snapshotName(filename))
+	}
+
+	if err := writeFileAtomic(filename, buf.Bytes(), snapshotPermissions); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+func readSnapshot(filename string) (*snapshot, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", filename, err)
+	}
+
+	var s snapshot
+
+	if err := json.NewDecoder(gz).Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode snapshot %s: %w", filename, err)
+	}
+
+	return &s, nil
+}
+
+// listSnapshots returns the names of the snapshots in dir, oldest first.
+func listSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), snapshotExt) {
+			names = append(names, snapshotName(entry.Name()))
+		}
+	}
+
+	// Names are timestamps, so they sort in time order.
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func snapshotName(filename string) string {
+	return strings.TrimSuffix(path.Base(filename), snapshotExt)
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Snapshots(t *testing.T) {
+	f := &fastmask{config: &config{dirs: &dirs{state: t.TempDir()}, profile: defaultProfile}}
+
+	maskedEmails := []fastmail.MaskedEmail{{ID: "masked-1", Email: "one@fastmail.com", State: "enabled"}}
+
+	for _, takenAt := range []string{"2022-06-01T12:00:00Z", "2022-05-01T12:00:00Z", "2022-05-02T12:00:00Z"} {
+		ts, err := time.Parse(time.RFC3339, takenAt)
+		require.NoError(t, err)
+
+		_, err = (&snapshot{TakenAt: ts, State: "1", MaskedEmails: maskedEmails}).write(f.snapshotsDir())
+		require.NoError(t, err)
+	}
+
+	names, err := listSnapshots(f.snapshotsDir())
+	require.NoError(t, err)
+	require.Equal(t, []string{"20220501T120000Z", "20220502T120000Z", "20220601T120000Z"}, names)
+
+	// A snapshot taken in the same second does not replace the earlier one.
+	_, err = (&snapshot{TakenAt: time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC), State: "2"}).write(f.snapshotsDir())
+	require.ErrorIs(t, err, errSnapshotExists)
+
+	s, err := f.findSnapshot("202206")
+	require.NoError(t, err)
+	require.Equal(t, maskedEmails, s.MaskedEmails)
+	require.Equal(t, "2022-06-01T12:00:00Z", s.TakenAt.Format(time.RFC3339))
+
+	s, err = f.findSnapshot(path.Join(f.snapshotsDir(), "20220501T120000Z.json.gz"))
+	require.NoError(t, err)
+	require.Equal(t, "1", s.State)
+
+	_, err = f.findSnapshot("202205")
+	require.ErrorIs(t, err, errSnapshotAmbiguous)
+
+	_, err = f.findSnapshot("2021")
+	require.ErrorIs(t, err, errSnapshotNotFound)
+
+	empty, err := listSnapshots(path.Join(f.snapshotsDir(), "missing"))
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
+
+func Test_Same_Account(t *testing.T) {
+	refs := []string{"20220601T120000Z", snapshotLive}
+
+	require.NoError(t, sameAccount(refs, []*snapshot{{AccountID: "u1"}, {AccountID: "u1"}}))
+	require.ErrorIs(t, sameAccount(refs, []*snapshot{{AccountID: "u1"}, {AccountID: "u2"}}), errSnapshotAccountMismatch)
+}
+
+func Test_Write_Masked_Email_Changes(t *testing.T) {
+	changes := fastmail.DiffMaskedEmails(
+		[]fastmail.MaskedEmail{
+			{ID: "masked-1", Email: "one@fastmail.com", State: "enabled", Description: "Shop"},
+			{ID: "masked-2", Email: "two@fastmail.com", State: "enabled"},
+		},
+		[]fastmail.MaskedEmail{
+			{ID: "masked-1", Email: "one@fastmail.com", State: "disabled", Description: "Shop"},
+			{ID: "masked-3", Email: "three@fastmail.com", State: "pending", ForDomain: "https://example.com"},
+		},
+	)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeMaskedEmailChanges(&buf, changes))
+	require.Equal(t, "CHANGE     EMAIL               ID        DETAILS\n"+
+		"updated    one@fastmail.com    masked-1  state: \"enabled\" -> \"disabled\"\n"+
+		"created    three@fastmail.com  masked-3  pending, https://example.com, \"\"\n"+
+		"destroyed  two@fastmail.com    masked-2  \n", buf.String())
+}
@@ -0,0 +1,482 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	flagWindow = "window"
+	flagTop    = "top"
+
+	defaultStatsWindow = "30d"
+	defaultStatsLimit  = 200
+	defaultStatsTop    = 5
+	defaultStatsMaxAge = time.Hour
+
+	// utcDateLayout is the JMAP UTCDate format, which must be in UTC with a 'Z' suffix.
+	utcDateLayout = "2006-01-02T15:04:05Z"
+)
+
+var (
+	errUnknownStatsFormat = errors.New("unknown stats format")
+
+	statsFormats = []string{exportCSV, exportJSON}
+
+	cacheMailStatsBucket = []byte("mailStats")
+)
+
+// maskStats are the messages delivered to a masked email within a window.
+type maskStats struct {
+	ID     string `json:"id"`
+	Email  string `json:"email"`
+	Domain string `json:"domain"`
+	State  string `json:"state"`
+	Window string `json:"window"`
+	// Messages is the number of messages received in the window, Junk those in the junk mailbox.
+	Messages      int    `json:"messages"`
+	Junk          int    `json:"junk"`
+	LastSender    string `json:"lastSender,omitempty"`
+	LastMessageAt string `json:"lastMessageAt,omitempty"`
+	// TopSenders are the Top most common sender domains of the newest Limit messages.
+	TopSenders []senderCount `json:"topSenders"`
+	Limit      int           `json:"limit"`
+	Top        int           `json:"top"`
+	ComputedAt time.Time     `json:"computedAt"`
+}
+
+// senderCount is the number of messages from a sender domain.
+type senderCount struct {
+	Domain   string `json:"domain"`
+	Messages int    `json:"messages"`
+}
+
+// mailStatsOptions select the messages counted by getMailStats.
+type mailStatsOptions struct {
+	Window time.Duration
+	// Limit is the number of messages per masked email sampled for top senders.
+	Limit int
+	Top   int
+	// MaxAge is how long cached stats are used for, offline uses them regardless.
+	MaxAge  time.Duration
+	Offline bool
+	Now     time.Time
+}
+
+func (f *fastmask) loadStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats [id or email...]",
+		Short: "Show mail statistics for masked emails.",
+		Long: "Count the messages each masked email received within --window, how many are in the junk mailbox, " +
+			"the last sender and the most common sender domains, to find masked emails worth disabling.\n\n" +
+			"Stats are cached for an hour, or --max-age, for the same --window, --limit and --top, and --offline " +
+			"shows only cached stats. Use --format to export them as CSV or JSON, to stdout or --file. The API " +
+			"token needs access to email, as well as masked emails.",
+		Example: "  fastmask stats -o table\n  fastmask stats --window 90d --state enabled --format csv -f stats.csv",
+		RunE:    f.runStats,
+	}
+
+	addFilterFlags(cmd)
+	addCacheFlags(cmd)
+	cmd.Flags().Lookup(flagMaxAge).Usage = "Use cached stats computed within this duration, and cached masked emails without " +
+		"checking for changes, eg. '10m' (default is 1h for stats)."
+	cmd.Flags().String(flagWindow, defaultStatsWindow, "Count messages received within this duration, eg. '90d'.")
+	cmd.Flags().Int(flagLimit, defaultStatsLimit, "Maximum number of messages per masked email sampled for top senders.")
+	cmd.Flags().Int(flagTop, defaultStatsTop, "Number of top sender domains shown.")
+	cmd.Flags().String(flagFormat, "", "Export format instead of --output, one of: "+strings.Join(statsFormats, ", ")+".")
+	cmd.Flags().StringP(flagFile, "f", "", "Write the export to a file instead of stdout.")
+
+	return cmd
+}
+
+// nolint:cyclop // flag parsing.
+func (f *fastmask) runStats(cmd *cobra.Command, args []string) error {
+	options := mailStatsOptions{Now: time.Now()}
+
+	window, err := cmd.Flags().GetString(flagWindow)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagWindow, err)
+	}
+
+	if options.Window, err = parseDuration(window); err != nil {
+		return fmt.Errorf("invalid --%s: %w", flagWindow, err)
+	}
+
+	if options.Limit, err = cmd.Flags().GetInt(flagLimit); err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagLimit, err)
+	}
+
+	if options.Top, err = cmd.Flags().GetInt(flagTop); err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagTop, err)
+	}
+
+	if options.Offline, err = cmd.Flags().GetBool(flagOffline); err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagOffline, err)
+	}
+
+	maxAge, err := cmd.Flags().GetString(flagMaxAge)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagMaxAge, err)
+	}
+
+	options.MaxAge = defaultStatsMaxAge
+	if maxAge != "" {
+		if options.MaxAge, err = parseDuration(maxAge); err != nil {
+			return fmt.Errorf("invalid --%s: %w", flagMaxAge, err)
+		}
+	}
+
+	format, err := cmd.Flags().GetString(flagFormat)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFormat, err)
+	}
+
+	if format != "" && oneOf(statsFormats...)(format) != nil {
+		return fmt.Errorf("%w: '%s', expected one of: %s", errUnknownStatsFormat, format, strings.Join(statsFormats, ", "))
+	}
+
+	filename, err := cmd.Flags().GetString(flagFile)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s: %w", flagFile, err)
+	}
+
+	stats, err := f.selectMailStats(cmd, args, options)
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return err
+	}
+
+	if format == "" && filename == "" {
+		return f.writeOutput(stats)
+	}
+
+	if format == "" {
+		format = exportJSON
+	}
+
+	if filename == "" {
+		return exportMailStats(os.Stdout, format, stats)
+	}
+
+	var buf bytes.Buffer
+
+	if err := exportMailStats(&buf, format, stats); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filename, buf.Bytes(), exportFilePermissions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported stats of %d masked emails to %s.\n", len(stats), filename)
+
+	return nil
+}
+
+func (f *fastmask) selectMailStats(cmd *cobra.Command, ids []string, options mailStatsOptions) ([]maskStats, error) {
+	maskedEmails, err := f.getCachedMaskedEmails(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	if maskedEmails, err = selectMaskedEmails(cmd, maskedEmails, ids); err != nil {
+		return nil, err
+	}
+
+	return f.getMailStats(cmd.Context(), maskedEmails, options)
+}
+
+// getMailStats returns the stats of each masked email, in the same order, using cached stats for
+// the same window, limit and top computed within options.MaxAge. Offline, masked emails without
+// cached stats are left out.
+func (f *fastmask) getMailStats(ctx context.Context, maskedEmails []fastmail.MaskedEmail, options mailStatsOptions) ([]maskStats, error) {
+	window := formatWindow(options.Window)
+
+	cached := map[string]maskStats{}
+
+	cache, err := f.openCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Not using cache: %s\n", err)
+	} else {
+		defer cache.Close()
+
+		if cached, err = cache.loadMailStats(); err != nil {
+			return nil, err
+		}
+	}
+
+	var missing []fastmail.MaskedEmail
+
+	for i := range maskedEmails {
+		s, ok := cached[maskedEmails[i].ID]
+		if !ok || !s.sameOptions(window, options) || (!options.Offline && options.Now.Sub(s.ComputedAt) >= options.MaxAge) {
+			delete(cached, maskedEmails[i].ID)
+
+			missing = append(missing, maskedEmails[i])
+		}
+	}
+
+	if options.Offline {
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  No cached %s stats for %d masked emails, run without --offline first.\n", window, len(missing))
+		}
+	} else if len(missing) > 0 {
+		client, err := f.newClient()
+		if err != nil {
+			return nil, err
+		}
+
+		computed, err := computeMailStats(ctx, client, missing, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range computed {
+			cached[s.ID] = s
+		}
+
+		if cache != nil {
+			if err := cache.putMailStats(computed); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Failed to cache stats: %s\n", err)
+			}
+		}
+	}
+
+	stats := make([]maskStats, 0, len(maskedEmails))
+
+	for i := range maskedEmails {
+		m := &maskedEmails[i]
+
+		if s, ok := cached[m.ID]; ok {
+			// The masked email may have changed since its stats were cached.
+			s.Email, s.Domain, s.State = m.Email, m.ForDomain, m.State
+			stats = append(stats, s)
+		}
+	}
+
+	return stats, nil
+}
+
+// computeMailStats queries the messages delivered to each masked email within the window.
+func computeMailStats(ctx context.Context, client *fastmail.Client, maskedEmails []fastmail.MaskedEmail, options mailStatsOptions) ([]maskStats, error) {
+	accountID, err := client.MailAccountID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mail account: %w", err)
+	}
+
+	mailboxes, err := client.GetMailboxes(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mailboxes: %w", err)
+	}
+
+	after := options.Now.Add(-options.Window).UTC().Format(utcDateLayout)
+
+	results, err := queryMaskedEmailMail(ctx, client, accountID, maskedEmails, func(m *fastmail.MaskedEmail) fastmail.EmailQuery {
+		return fastmail.EmailQuery{Filter: fastmail.EmailFilter{After: after}, Recipient: m.Email, Limit: options.Limit}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var junkResults []fastmail.EmailQueryResult
+
+	if junk, ok := fastmail.MailboxByRole(mailboxes, fastmail.MailboxRoleJunk); ok {
+		// Only the total is needed, so fetch a single message.
+		junkResults, err = queryMaskedEmailMail(ctx, client, accountID, maskedEmails, func(m *fastmail.MaskedEmail) fastmail.EmailQuery {
+			return fastmail.EmailQuery{Filter: fastmail.EmailFilter{InMailbox: junk.ID, After: after}, Recipient: m.Email, Limit: 1}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats := make([]maskStats, 0, len(maskedEmails))
+
+	for i := range maskedEmails {
+		var junk *fastmail.EmailQueryResult
+		if junkResults != nil {
+			junk = &junkResults[i]
+		}
+
+		stats = append(stats, mailStatsFor(&maskedEmails[i], &results[i], junk, options))
+	}
+
+	return stats, nil
+}
+
+// mailStatsFor returns the stats of m from the newest messages it received and those in junk,
+// which is nil if the account has no junk mailbox.
+func mailStatsFor(m *fastmail.MaskedEmail, result, junk *fastmail.EmailQueryResult, options mailStatsOptions) maskStats {
+	s := maskStats{
+		ID:         m.ID,
+		Email:      m.Email,
+		Domain:     m.ForDomain,
+		State:      m.State,
+		Window:     formatWindow(options.Window),
+		Messages:   result.Total,
+		TopSenders: []senderCount{},
+		Limit:      options.Limit,
+		Top:        options.Top,
+		ComputedAt: options.Now,
+	}
+
+	if junk != nil {
+		s.Junk = junk.Total
+	}
+
+	if len(result.Emails) > 0 {
+		s.LastSender = result.Emails[0].Sender()
+		s.LastMessageAt = result.Emails[0].ReceivedAt
+	}
+
+	counts := map[string]int{}
+
+	for i := range result.Emails {
+		if domain := addressDomain(result.Emails[i].Sender()); domain != "" {
+			counts[domain]++
+		}
+	}
+
+	for domain, n := range counts {
+		s.TopSenders = append(s.TopSenders, senderCount{Domain: domain, Messages: n})
+	}
+
+	sort.Slice(s.TopSenders, func(i, j int) bool {
+		a, b := s.TopSenders[i], s.TopSenders[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+
+		return a.Domain < b.Domain
+	})
+
+	if options.Top >= 0 && len(s.TopSenders) > options.Top {
+		s.TopSenders = s.TopSenders[:options.Top]
+	}
+
+	return s
+}
+
+// sameOptions reports whether the stats were computed with the same window, limit and top senders.
+func (s *maskStats) sameOptions(window string, options mailStatsOptions) bool {
+	return s.Window == window && s.Limit == options.Limit && s.Top == options.Top
+}
+
+// formatWindow formats a window in whole days where possible, eg. '30d'.
+func formatWindow(d time.Duration) string {
+	day := hoursPerDay * time.Hour
+
+	if d > 0 && d%day == 0 {
+		return strconv.Itoa(int(d/day)) + "d"
+	}
+
+	return d.String()
+}
+
+// loadMailStats returns the cached stats of the profile by masked email ID.
+func (c *maskedEmailCache) loadMailStats() (map[string]maskStats, error) {
+	stats := map[string]maskStats{}
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.profile)
+		if b == nil {
+			return nil
+		}
+
+		items := b.Bucket(cacheMailStatsBucket)
+		if items == nil {
+			return nil
+		}
+
+		return items.ForEach(func(k, v []byte) error {
+			var s maskStats
+			if err := json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("failed to decode cached stats: %w", err)
+			}
+
+			stats[string(k)] = s
+
+			return nil
+		})
+	})
+	if err != nil {
+		// nolint:wrapcheck // errors are wrapped above.
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// putMailStats stores the stats, replacing those cached for the same masked emails.
+func (c *maskedEmailCache) putMailStats(stats []maskStats) error {
+	return c.update(func(b *bolt.Bucket) error {
+		items, err := b.CreateBucketIfNotExists(cacheMailStatsBucket)
+		if err != nil {
+			return fmt.Errorf("failed to update cache: %w", err)
+		}
+
+		for i := range stats {
+			v, err := json.Marshal(&stats[i])
+			if err != nil {
+				return fmt.Errorf("failed to encode stats: %w", err)
+			}
+
+			if err := items.Put([]byte(stats[i].ID), v); err != nil {
+				return fmt.Errorf("failed to update cache: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// exportMailStats writes the stats to out in the given format.
+func exportMailStats(out io.Writer, format string, stats []maskStats) error {
+	switch format {
+	case exportJSON:
+		return writeJSON(out, stats)
+	case exportCSV:
+		rows := [][]string{{"id", "email", "state", "domain", "window", "messages", "junk", "last_sender", "last_message_at", "top_senders"}}
+
+		for i := range stats {
+			s := &stats[i]
+			rows = append(rows, []string{
+				s.ID, s.Email, s.State, s.Domain, s.Window, strconv.Itoa(s.Messages), strconv.Itoa(s.Junk),
+				s.LastSender, s.LastMessageAt, formatSenderCounts(s.TopSenders, " ", "%s:%d"),
+			})
+		}
+
+		return writeCSV(out, rows)
+	}
+
+	return fmt.Errorf("%w: '%s'", errUnknownStatsFormat, format)
+}
+
+// formatSenderCounts formats each sender count with format, eg. '%s (%d)', joined by sep.
+func formatSenderCounts(senders []senderCount, sep, format string) string {
+	formatted := make([]string, 0, len(senders))
+	for _, s := range senders {
+		formatted = append(formatted, fmt.Sprintf(format, s.Domain, s.Messages))
+	}
+
+	return strings.Join(formatted, sep)
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Mail_Stats(t *testing.T) {
+	now := time.Date(2022, 6, 30, 12, 0, 0, 0, time.UTC)
+	options := mailStatsOptions{Window: 30 * 24 * time.Hour, Top: 2, Now: now}
+
+	m := &fastmail.MaskedEmail{ID: "masked-1", Email: "one@fastmail.com", ForDomain: "shop.com", State: fastmail.StateEnabled}
+
+	sent := func(from, receivedAt string) fastmail.Email {
+		return fastmail.Email{From: []fastmail.EmailAddress{{Email: from}}, ReceivedAt: receivedAt}
+	}
+
+	result := &fastmail.EmailQueryResult{
+		Emails: []fastmail.Email{
+			sent("offers@spam.example", "2022-06-29T00:00:00Z"),
+			sent("orders@shop.com", "2022-06-20T00:00:00Z"),
+			sent("offers@spam.example", "2022-06-10T00:00:00Z"),
+			sent("news@other.example", "2022-06-05T00:00:00Z"),
+			sent("deals@another.example", "2022-06-02T00:00:00Z"),
+		},
+		Total: 12,
+	}
+
+	s := mailStatsFor(m, result, &fastmail.EmailQueryResult{Total: 3}, options)
+	require.Equal(t, maskStats{
+		ID:            "masked-1",
+		Email:         "one@fastmail.com",
+		Domain:        "shop.com",
+		State:         fastmail.StateEnabled,
+		Window:        "30d",
+		Messages:      12,
+		Junk:          3,
+		LastSender:    "offers@spam.example",
+		LastMessageAt: "2022-06-29T00:00:00Z",
+		TopSenders:    []senderCount{{Domain: "spam.example", Messages: 2}, {Domain: "another.example", Messages: 1}},
+		Top:           2,
+		ComputedAt:    now,
+	}, s)
+
+	// Without a junk mailbox or messages.
+	empty := mailStatsFor(m, &fastmail.EmailQueryResult{}, nil, options)
+	require.Zero(t, empty.Junk)
+	require.Empty(t, empty.LastSender)
+	require.Equal(t, []senderCount{}, empty.TopSenders)
+
+	require.Equal(t, "12h0m0s", formatWindow(12*time.Hour))
+
+	var csv bytes.Buffer
+
+	require.NoError(t, exportMailStats(&csv, exportCSV, []maskStats{s}))
+	require.Equal(t, "id,email,state,domain,window,messages,junk,last_sender,last_message_at,top_senders\n"+
+		"masked-1,one@fastmail.com,enabled,shop.com,30d,12,3,offers@spam.example,2022-06-29T00:00:00Z,spam.example:2 another.example:1\n", csv.String())
+
+	t.Run("Cached", func(t *testing.T) {
+		f := &fastmask{config: &config{dirs: &dirs{state: t.TempDir()}, profile: defaultProfile}}
+
+		cache, err := f.openCache()
+		require.NoError(t, err)
+		require.NoError(t, cache.putMailStats([]maskStats{s}))
+		require.NoError(t, cache.Close())
+
+		maskedEmails := []fastmail.MaskedEmail{
+			{ID: "masked-1", Email: "one@fastmail.com", ForDomain: "shop.com", State: fastmail.StateDisabled},
+			{ID: "masked-2", Email: "two@fastmail.com"},
+		}
+
+		// Offline only cached stats for the same window are returned, with the current masked email.
+		options.Offline = true
+
+		stats, err := f.getMailStats(context.TODO(), maskedEmails, options)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		require.Equal(t, fastmail.StateDisabled, stats[0].State)
+		require.Equal(t, 12, stats[0].Messages)
+
+		// Stats with other top senders or another window are not reused.
+		options.Top = 10
+
+		stats, err = f.getMailStats(context.TODO(), maskedEmails, options)
+		require.NoError(t, err)
+		require.Empty(t, stats)
+
+		options.Top = 2
+		options.Window = 7 * 24 * time.Hour
+
+		stats, err = f.getMailStats(context.TODO(), maskedEmails, options)
+		require.NoError(t, err)
+		require.Empty(t, stats)
+	})
+}
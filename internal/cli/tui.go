@@ -0,0 +1,528 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	// tuiRefreshInterval is how often the TUI checks for changes made elsewhere, eg. by a browser extension.
+	tuiRefreshInterval = 30 * time.Second
+
+	// tuiDetailLines is the height of the detail pane, one line per field of writeMaskedEmailDetail.
+	tuiDetailLines = 9
+	// tuiChromeLines are the lines around the list: header, separator, status and help.
+	tuiChromeLines = 4
+	// tuiDefaultListLines is used until the terminal size is known.
+	tuiDefaultListLines = 10
+	// tuiMaxEmailWidth caps the email column so the domain and description remain visible.
+	tuiMaxEmailWidth = 40
+
+	tuiHelp = "↑/↓ move  / filter  e enable  x disable  d delete  c description  y copy  r refresh  q quit"
+)
+
+var errNotTerminal = errors.New("the TUI requires an interactive terminal")
+
+type tuiMode int
+
+const (
+	tuiBrowse tuiMode = iota
+	tuiFilter
+	tuiEditDescription
+	tuiConfirmDelete
+)
+
+func (f *fastmask) loadTUICmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Browse masked emails in an interactive terminal UI.",
+		Long: "Browse masked emails in a full-screen terminal UI. Type '/' to filter as you type, the " +
+			"selected masked email can be enabled, disabled, deleted, have its description edited or its " +
+			"address copied to the clipboard. Changes made elsewhere are picked up automatically.",
+		Args: cobra.NoArgs,
+		RunE: f.runTUI,
+	}
+}
+
+func (f *fastmask) runTUI(cmd *cobra.Command, _ []string) error {
+	if !term.IsTerminal(int(os.Stdout.Fd())) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errNotTerminal
+	}
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	list, err := client.GetMaskedEmailList(cmd.Context())
+	if err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	p := tea.NewProgram(newTUIModel(cmd.Context(), client, list), tea.WithAltScreen())
+
+	if err := p.Start(); err != nil {
+		return fmt.Errorf("failed to run TUI: %w", err)
+	}
+
+	return nil
+}
+
+type (
+	// tuiLoadedMsg is sent when masked emails have been fetched.
+	tuiLoadedMsg struct {
+		list *fastmail.MethodResponseMaskedEmailGet
+	}
+	// tuiUpdatedMsg is sent when a masked email has been updated.
+	tuiUpdatedMsg struct{ status string }
+	// tuiErrMsg is sent when a request failed.
+	tuiErrMsg struct{ err error }
+	// tuiTickMsg triggers checking for changes.
+	tuiTickMsg struct{}
+)
+
+type tuiModel struct {
+	ctx    context.Context
+	client *fastmail.Client
+
+	// maskedEmails are all masked emails, state is the state string they were fetched at.
+	maskedEmails []fastmail.MaskedEmail
+	state        string
+	// visible are the masked emails matching filter, in display order.
+	visible []fastmail.MaskedEmail
+	filter  string
+
+	mode   tuiMode
+	input  string
+	status string
+
+	cursor int
+	offset int
+	width  int
+	height int
+}
+
+func newTUIModel(ctx context.Context, client *fastmail.Client, list *fastmail.MethodResponseMaskedEmailGet) *tuiModel {
+	m := &tuiModel{ctx: ctx, client: client}
+	m.setMaskedEmails(list)
+
+	return m
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.tick()
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+	case tea.KeyMsg:
+		return m, m.handleKey(msg)
+	case tuiLoadedMsg:
+		// The state only changes when a masked email changed, so the list is left alone otherwise.
+		if msg.list.State != m.state {
+			m.setMaskedEmails(msg.list)
+		}
+	case tuiUpdatedMsg:
+		m.status = msg.status
+
+		return m, m.load()
+	case tuiErrMsg:
+		m.status = "Error: " + msg.err.Error()
+		if reauthNeeded(msg.err) {
+			m.status = "Authentication failed. Please run 'fastmask login'."
+		}
+	case tuiTickMsg:
+		return m, tea.Batch(m.load(), m.tick())
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	switch m.mode {
+	case tuiFilter:
+		input, done, ok := editInput(m.input, msg)
+		m.input = input
+		m.applyFilter(input)
+
+		if done {
+			m.mode = tuiBrowse
+
+			if !ok {
+				m.input = ""
+				m.applyFilter("")
+			}
+		}
+
+		return nil
+	case tuiEditDescription:
+		input, done, ok := editInput(m.input, msg)
+		m.input = input
+
+		if !done {
+			return nil
+		}
+
+		m.mode = tuiBrowse
+
+		if selected := m.selected(); ok && selected != nil && input != selected.Description {
+			return m.update(selected.ID, fastmail.MaskedEmailUpdate{Description: &input},
+				fmt.Sprintf("Updated description of %s.", selected.Email))
+		}
+
+		return nil
+	case tuiConfirmDelete:
+		m.mode = tuiBrowse
+
+		if selected := m.selected(); msg.String() == "y" && selected != nil {
+			return m.setState(selected, fastmail.StateDeleted)
+		}
+
+		m.status = "Delete cancelled."
+
+		return nil
+	case tuiBrowse:
+	}
+
+	return m.handleBrowseKey(msg)
+}
+
+func (m *tuiModel) handleBrowseKey(msg tea.KeyMsg) tea.Cmd {
+	listLines := m.listLines()
+
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-listLines)
+	case "pgdown":
+		m.moveCursor(listLines)
+	case "home", "g":
+		m.moveCursor(-len(m.visible))
+	case "end", "G":
+		m.moveCursor(len(m.visible))
+	case "/":
+		m.mode, m.input = tuiFilter, m.filter
+	case "esc":
+		m.applyFilter("")
+	case "r":
+		m.status = "Refreshing..."
+		m.state = ""
+
+		return m.load()
+	}
+
+	selected := m.selected()
+	if selected == nil {
+		return nil
+	}
+
+	switch msg.String() {
+	case "e":
+		return m.setState(selected, fastmail.StateEnabled)
+	case "x":
+		return m.setState(selected, fastmail.StateDisabled)
+	case "d":
+		m.mode = tuiConfirmDelete
+	case "c":
+		m.mode, m.input = tuiEditDescription, selected.Description
+	case "y":
+		if err := clipboard.WriteAll(selected.Email); err != nil {
+			m.status = "Error: failed to copy to clipboard: " + err.Error()
+		} else {
+			m.status = fmt.Sprintf("Copied %s to the clipboard.", selected.Email)
+		}
+	}
+
+	return nil
+}
+
+// editInput applies a key press to a single line input. done is true when editing finished, ok
+// is false if it was cancelled.
+func editInput(input string, msg tea.KeyMsg) (result string, done, ok bool) {
+	// nolint:exhaustive // other keys are ignored.
+	switch msg.Type {
+	case tea.KeyEnter:
+		return input, true, true
+	case tea.KeyEsc:
+		return input, true, false
+	case tea.KeyBackspace:
+		if input != "" {
+			_, size := utf8.DecodeLastRuneInString(input)
+			input = input[:len(input)-size]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		input += string(msg.Runes)
+	}
+
+	return input, false, false
+}
+
+func (m *tuiModel) setState(selected *fastmail.MaskedEmail, state string) tea.Cmd {
+	if selected.State == state {
+		m.status = fmt.Sprintf("%s is already %s.", selected.Email, state)
+
+		return nil
+	}
+
+	return m.update(selected.ID, fastmail.MaskedEmailUpdate{State: &state},
+		fmt.Sprintf("Set %s to %s.", selected.Email, state))
+}
+
+func (m *tuiModel) update(id string, update fastmail.MaskedEmailUpdate, status string) tea.Cmd {
+	ctx, client := m.ctx, m.client
+
+	return func() tea.Msg {
+		if err := client.UpdateMaskedEmails(ctx, map[string]fastmail.MaskedEmailUpdate{id: update}); err != nil {
+			return tuiErrMsg{err}
+		}
+
+		return tuiUpdatedMsg{status}
+	}
+}
+
+func (m *tuiModel) load() tea.Cmd {
+	ctx, client := m.ctx, m.client
+
+	return func() tea.Msg {
+		list, err := client.GetMaskedEmailList(ctx)
+		if err != nil {
+			return tuiErrMsg{err}
+		}
+
+		return tuiLoadedMsg{list}
+	}
+}
+
+func (m *tuiModel) tick() tea.Cmd {
+	return tea.Tick(tuiRefreshInterval, func(time.Time) tea.Msg { return tuiTickMsg{} })
+}
+
+func (m *tuiModel) setMaskedEmails(list *fastmail.MethodResponseMaskedEmailGet) {
+	m.maskedEmails, m.state = list.List, list.State
+	m.applyFilter(m.filter)
+}
+
+// applyFilter updates the visible masked emails, keeping the selected masked email selected
+// if it is still visible.
+func (m *tuiModel) applyFilter(filter string) {
+	var selectedID string
+	if selected := m.selected(); selected != nil {
+		selectedID = selected.ID
+	}
+
+	m.filter = filter
+
+	if strings.TrimSpace(filter) == "" {
+		m.visible = append([]fastmail.MaskedEmail(nil), m.maskedEmails...)
+		// nolint:errcheck // the sort field is valid.
+		_ = fastmail.SortMaskedEmails(m.visible, "-"+fastmail.SortByCreatedAt)
+	} else {
+		results := fastmail.SearchMaskedEmails(m.maskedEmails, filter)
+
+		m.visible = make([]fastmail.MaskedEmail, len(results))
+		for i := range results {
+			m.visible[i] = results[i].MaskedEmail
+		}
+	}
+
+	m.cursor = 0
+
+	for i := range m.visible {
+		if m.visible[i].ID == selectedID {
+			m.cursor = i
+
+			break
+		}
+	}
+
+	m.scroll()
+}
+
+func (m *tuiModel) selected() *fastmail.MaskedEmail {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return nil
+	}
+
+	return &m.visible[m.cursor]
+}
+
+func (m *tuiModel) moveCursor(delta int) {
+	m.cursor += delta
+
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+
+	m.scroll()
+}
+
+// scroll keeps the cursor within the visible part of the list.
+func (m *tuiModel) scroll() {
+	listLines := m.listLines()
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+
+	if m.cursor >= m.offset+listLines {
+		m.offset = m.cursor - listLines + 1
+	}
+
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+func (m *tuiModel) listLines() int {
+	if m.height == 0 {
+		return tuiDefaultListLines
+	}
+
+	if lines := m.height - tuiDetailLines - tuiChromeLines; lines > 1 {
+		return lines
+	}
+
+	return 1
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	header := fmt.Sprintf("fastmask  %d of %d masked emails", len(m.visible), len(m.maskedEmails))
+	if m.filter != "" {
+		header += fmt.Sprintf("  filter: %q", m.filter)
+	}
+
+	b.WriteString(m.line(header))
+
+	emailWidth := 0
+
+	for i := range m.visible {
+		if n := utf8.RuneCountInString(m.visible[i].Email); n > emailWidth {
+			emailWidth = n
+		}
+	}
+
+	if emailWidth > tuiMaxEmailWidth {
+		emailWidth = tuiMaxEmailWidth
+	}
+
+	listLines := m.listLines()
+
+	for i := m.offset; i < m.offset+listLines; i++ {
+		if i >= len(m.visible) {
+			b.WriteString("\n")
+
+			continue
+		}
+
+		v := &m.visible[i]
+
+		marker := "  "
+		if i == m.cursor {
+			marker = "> "
+		}
+
+		b.WriteString(m.line(fmt.Sprintf("%s%-*s  %-8s  %s  %s", marker, emailWidth, truncate(v.Email, emailWidth),
+			v.State, v.ForDomain, tableCell(v.Description))))
+	}
+
+	b.WriteString(m.line(strings.Repeat("─", m.lineWidth())))
+
+	var detail strings.Builder
+
+	if selected := m.selected(); selected != nil {
+		// nolint:errcheck // writing to a strings.Builder does not fail.
+		_ = writeMaskedEmailDetail(&detail, selected)
+	}
+
+	details := strings.Split(strings.TrimSuffix(detail.String(), "\n"), "\n")
+	for i := 0; i < tuiDetailLines; i++ {
+		if i < len(details) {
+			b.WriteString(m.line(details[i]))
+		} else {
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(m.line(m.statusLine()))
+	b.WriteString(truncate(tuiHelp, m.lineWidth()))
+
+	return b.String()
+}
+
+func (m *tuiModel) statusLine() string {
+	switch m.mode {
+	case tuiFilter:
+		return "/" + m.input + "█"
+	case tuiEditDescription:
+		return "Description: " + m.input + "█  (enter to save, esc to cancel)"
+	case tuiConfirmDelete:
+		if selected := m.selected(); selected != nil {
+			return fmt.Sprintf("Delete %s? It can be restored later. (y/N)", selected.Email)
+		}
+	case tuiBrowse:
+	}
+
+	return m.status
+}
+
+// line returns s truncated to the terminal width, with a trailing newline.
+func (m *tuiModel) line(s string) string {
+	return truncate(s, m.lineWidth()) + "\n"
+}
+
+func (m *tuiModel) lineWidth() int {
+	if m.width == 0 {
+		return 80 // nolint:gomnd // default terminal width.
+	}
+
+	return m.width
+}
+
+// truncate shortens s to at most n runes, ending with an ellipsis if it was shortened.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	if n <= 1 {
+		return string([]rune(s)[:n])
+	}
+
+	return string([]rune(s)[:n-1]) + "…"
+}
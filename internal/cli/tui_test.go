@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_TUI_Model(t *testing.T) {
+	keys := func(m *tuiModel, s string) {
+		for _, r := range s {
+			m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	newModel := func() *tuiModel {
+		return newTUIModel(context.TODO(), nil, &fastmail.MethodResponseMaskedEmailGet{
+			State: "1",
+			List: []fastmail.MaskedEmail{
+				{ID: "masked-1", Email: "one@fastmail.com", ForDomain: "shop.example.com", State: "enabled", CreatedAt: "2022-01-01T00:00:00Z"},
+				{ID: "masked-2", Email: "two@fastmail.com", ForDomain: "news.example.org", State: "disabled", CreatedAt: "2022-02-01T00:00:00Z"},
+				{ID: "masked-3", Email: "three@fastmail.com", ForDomain: "forum.example.net", State: "pending", CreatedAt: "2022-03-01T00:00:00Z"},
+			},
+		})
+	}
+
+	t.Run("Newest First", func(t *testing.T) {
+		m := newModel()
+		require.Len(t, m.visible, 3)
+		require.Equal(t, "masked-3", m.selected().ID)
+	})
+
+	t.Run("Navigation", func(t *testing.T) {
+		m := newModel()
+		keys(m, "jj")
+		require.Equal(t, "masked-1", m.selected().ID)
+		keys(m, "j")
+		require.Equal(t, "masked-1", m.selected().ID, "cursor stops at the end")
+		keys(m, "g")
+		require.Equal(t, "masked-3", m.selected().ID)
+	})
+
+	t.Run("Filter As You Type", func(t *testing.T) {
+		m := newModel()
+		keys(m, "/news")
+		require.Equal(t, tuiFilter, m.mode)
+		require.Len(t, m.visible, 1)
+		require.Equal(t, "masked-2", m.selected().ID)
+		require.Contains(t, m.View(), "two@fastmail.com")
+		require.NotContains(t, m.View(), "three@fastmail.com")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		require.Equal(t, tuiBrowse, m.mode)
+		require.Equal(t, "news", m.filter)
+
+		m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		require.Len(t, m.visible, 3)
+		require.Equal(t, "masked-2", m.selected().ID, "selection is kept when the filter is cleared")
+	})
+
+	t.Run("Refresh Keeps Unchanged State", func(t *testing.T) {
+		m := newModel()
+		m.Update(tuiLoadedMsg{&fastmail.MethodResponseMaskedEmailGet{State: "1"}})
+		require.Len(t, m.visible, 3)
+
+		m.Update(tuiLoadedMsg{&fastmail.MethodResponseMaskedEmailGet{State: "2", List: m.maskedEmails[:1]}})
+		require.Len(t, m.visible, 1)
+	})
+
+	t.Run("Cancel Delete", func(t *testing.T) {
+		m := newModel()
+		keys(m, "d")
+		require.Equal(t, tuiConfirmDelete, m.mode)
+		require.Contains(t, m.View(), "Delete three@fastmail.com?")
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+		require.Nil(t, cmd)
+		require.Equal(t, tuiBrowse, m.mode)
+	})
+
+	t.Run("Already In State", func(t *testing.T) {
+		m := newModel()
+		keys(m, "j")
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+		require.Nil(t, cmd)
+		require.Contains(t, m.status, "already disabled")
+	})
+}
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+const (
+	watchMinBackoff = time.Second
+	watchMaxBackoff = time.Minute
+)
+
+// watchEvent is a masked email change written by watch as a line of JSON.
+type watchEvent struct {
+	At time.Time `json:"at"`
+	fastmail.MaskedEmailChange
+}
+
+// maskedEmailWatcher writes the changes to masked emails as they are pushed by the server.
+type maskedEmailWatcher struct {
+	client       *fastmail.Client
+	out          *json.Encoder
+	state        string
+	maskedEmails map[string]fastmail.MaskedEmail
+	now          func() time.Time
+	// onChange, if set, is called with each change after it is written.
+	onChange func(ctx context.Context, e *watchEvent)
+}
+
+func (f *fastmask) loadWatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Stream masked email changes as they happen.",
+		Long: "Print each masked email created, destroyed, or with a changed state, domain, description or url " +
+			"as a line of JSON, as soon as Fastmail pushes the change. Reconnects with backoff when the " +
+			"connection is lost, until interrupted.",
+		Example: "  fastmask watch\n  fastmask watch | jq 'select(.type == \"created\") | .email'",
+		Args:    cobra.NoArgs,
+		RunE:    f.runWatch,
+	}
+}
+
+func (f *fastmask) runWatch(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	w := newMaskedEmailWatcher(client, os.Stdout)
+
+	if err := w.load(ctx); err != nil {
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to get masked emails: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "👀 Watching %d masked emails, press Ctrl+C to stop.\n", len(w.maskedEmails))
+
+	backoff := watchMinBackoff
+
+	for {
+		connectedAt := time.Now()
+
+		err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if reauthNeeded(err) {
+			fmt.Println("🛑 Authentication failed. Please run 'fastmask login'.")
+
+			return nil
+		}
+
+		// A connection that lasted a while was not failing, start backing off again.
+		if time.Since(connectedAt) > watchMaxBackoff {
+			backoff = watchMinBackoff
+		}
+
+		fmt.Fprintf(os.Stderr, "⚠️  Watch interrupted: %s, reconnecting in %s.\n", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > watchMaxBackoff {
+			backoff = watchMaxBackoff
+		}
+	}
+}
+
+func newMaskedEmailWatcher(client *fastmail.Client, out io.Writer) *maskedEmailWatcher {
+	return &maskedEmailWatcher{
+		client:       client,
+		out:          json.NewEncoder(out),
+		maskedEmails: map[string]fastmail.MaskedEmail{},
+		now:          time.Now,
+	}
+}
+
+// load fetches all masked emails, to compare changes with.
+func (w *maskedEmailWatcher) load(ctx context.Context) error {
+	list, err := w.client.GetMaskedEmailList(ctx)
+	if err != nil {
+		// nolint:wrapcheck // callers wrap errors.
+		return err
+	}
+
+	w.state = list.State
+	w.maskedEmails = make(map[string]fastmail.MaskedEmail, len(list.List))
+
+	for _, m := range list.List {
+		w.maskedEmails[m.ID] = m
+	}
+
+	return nil
+}
+
+// watch subscribes to masked email state changes and writes the changes until the subscription ends.
+func (w *maskedEmailWatcher) watch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A WebSocket carries requests and pushes over one connection, otherwise HTTP is used.
+	if err := w.client.ConnectWebSocket(ctx); errors.Is(err, fastmail.ErrUnauthorized) {
+		// nolint:wrapcheck // callers wrap errors.
+		return err
+	}
+
+	defer w.client.CloseWebSocket()
+
+	sub, err := w.client.Subscribe(ctx, fastmail.TypeMaskedEmail)
+	if err != nil {
+		// nolint:wrapcheck // callers wrap errors.
+		return err
+	}
+
+	// Catch up on changes made while not subscribed.
+	if err := w.sync(ctx); err != nil {
+		return err
+	}
+
+	for change := range sub.Events() {
+		state, ok := change.State(w.client.AccountID(), fastmail.TypeMaskedEmail)
+		if !ok || state == w.state {
+			continue
+		}
+
+		if err := w.sync(ctx); err != nil {
+			return err
+		}
+	}
+
+	// nolint:wrapcheck // callers wrap errors.
+	return sub.Err()
+}
+
+// sync fetches the changes since the last state and writes them.
+func (w *maskedEmailWatcher) sync(ctx context.Context) error {
+	changes, err := getMaskedEmailChanges(ctx, w.client, w.state)
+	if err != nil {
+		if !cannotCalculateChanges(err) {
+			return err
+		}
+
+		return w.reload(ctx)
+	}
+
+	var oldList []fastmail.MaskedEmail
+
+	for _, m := range changes.Updated {
+		if old, ok := w.maskedEmails[m.ID]; ok {
+			oldList = append(oldList, old)
+		}
+
+		w.maskedEmails[m.ID] = m
+	}
+
+	for _, id := range changes.Destroyed {
+		if old, ok := w.maskedEmails[id]; ok {
+			oldList = append(oldList, old)
+		}
+
+		delete(w.maskedEmails, id)
+	}
+
+	w.state = changes.State
+
+	return w.write(ctx, fastmail.DiffMaskedEmails(oldList, changes.Updated))
+}
+
+// reload fetches all masked emails again, writing what changed since they were last fetched.
+func (w *maskedEmailWatcher) reload(ctx context.Context) error {
+	oldList := make([]fastmail.MaskedEmail, 0, len(w.maskedEmails))
+	for _, m := range w.maskedEmails {
+		oldList = append(oldList, m)
+	}
+
+	if err := w.load(ctx); err != nil {
+		return err
+	}
+
+	newList := make([]fastmail.MaskedEmail, 0, len(w.maskedEmails))
+	for _, m := range w.maskedEmails {
+		newList = append(newList, m)
+	}
+
+	return w.write(ctx, fastmail.DiffMaskedEmails(oldList, newList))
+}
+
+func (w *maskedEmailWatcher) write(ctx context.Context, changes []fastmail.MaskedEmailChange) error {
+	at := w.now().UTC()
+
+	for _, change := range changes {
+		e := &watchEvent{At: at, MaskedEmailChange: change}
+
+		if err := w.out.Encode(e); err != nil {
+			return fmt.Errorf("failed to write change: %w", err)
+		}
+
+		if w.onChange != nil {
+			w.onChange(ctx, e)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Masked_Email_Watcher_Sync(t *testing.T) {
+	api := &fakeMaskedEmailAPI{
+		state: "1",
+		maskedEmails: map[string]fastmail.MaskedEmail{
+			"masked-1": {ID: "masked-1", Email: "one@fastmail.com", State: fastmail.StateEnabled},
+			"masked-2": {ID: "masked-2", Email: "two@fastmail.com", State: fastmail.StateEnabled},
+		},
+	}
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	apiEndpoint := fastmail.APIEndpoint
+	fastmail.APIEndpoint = server.URL
+
+	defer func() { fastmail.APIEndpoint = apiEndpoint }()
+
+	var out bytes.Buffer
+
+	w := newMaskedEmailWatcher(fastmail.NewClient("fastmask").SetTokenAuthCredentials("account-1", "token"), &out)
+	w.now = func() time.Time { return time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	ctx := context.TODO()
+
+	require.NoError(t, w.load(ctx))
+	require.Len(t, w.maskedEmails, 2)
+
+	api.state = "2"
+	api.maskedEmails["masked-1"] = fastmail.MaskedEmail{ID: "masked-1", Email: "one@fastmail.com", State: fastmail.StateDisabled}
+	api.maskedEmails["masked-3"] = fastmail.MaskedEmail{ID: "masked-3", Email: "three@fastmail.com", State: fastmail.StatePending}
+	delete(api.maskedEmails, "masked-2")
+	api.changes = map[string]interface{}{
+		"accountId": "account-1", "oldState": "1", "newState": "2",
+		"created": []string{"masked-3"}, "updated": []string{"masked-1"}, "destroyed": []string{"masked-2"},
+	}
+
+	require.NoError(t, w.sync(ctx))
+	require.Equal(t, "2", w.state)
+	require.Equal(t, `{"at":"2022-06-01T12:00:00Z","type":"updated","id":"masked-1","email":"one@fastmail.com","fields":["state"],`+
+		`"old":{"id":"masked-1","state":"enabled","email":"one@fastmail.com"},"new":{"id":"masked-1","state":"disabled","email":"one@fastmail.com"}}
+{"at":"2022-06-01T12:00:00Z","type":"created","id":"masked-3","email":"three@fastmail.com","new":{"id":"masked-3","state":"pending","email":"three@fastmail.com"}}
+{"at":"2022-06-01T12:00:00Z","type":"destroyed","id":"masked-2","email":"two@fastmail.com","old":{"id":"masked-2","state":"enabled","email":"two@fastmail.com"}}
+`, out.String())
+}
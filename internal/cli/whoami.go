@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+// whoami describes the account the stored credentials belong to.
+type whoami struct {
+	Profile               string `json:"profile"`
+	AccountID             string `json:"accountId"`
+	DisplayName           string `json:"displayName,omitempty"`
+	Username              string `json:"username"`
+	MaskedEmailCapability bool   `json:"maskedEmailCapability"`
+}
+
+func (f *fastmask) loadWhoamiCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "whoami",
+		Aliases: []string{"status"},
+		Short:   "Show the logged in account.",
+		Long:    "Verify the stored access token and show the account it belongs to, exiting with an error if it is invalid.",
+		Args:    cobra.NoArgs,
+		RunE:    f.runWhoami,
+	}
+}
+
+func (f *fastmask) runWhoami(cmd *cobra.Command, _ []string) error {
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+
+	accountID := client.AccountID()
+
+	session, err := client.GetSession(cmd.Context())
+	if err != nil {
+		// Unlike other commands this is an error, so scripts can check the token by the exit code.
+		if reauthNeeded(err) {
+			return fmt.Errorf("%w: please run 'fastmask login'", fastmail.ErrUnauthorized)
+		}
+
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	displayName := session.DisplayName
+	if displayName == "" {
+		displayName = session.Accounts[accountID].Name
+	}
+
+	return f.writeOutput(&whoami{
+		Profile:     f.config.profile,
+		AccountID:   accountID,
+		DisplayName: displayName,
+		Username:    session.Username,
+		MaskedEmailCapability: session.HasCapability(fastmail.CapabilityMaskedEmail) ||
+			session.AccountHasCapability(accountID, fastmail.CapabilityMaskedEmail),
+	})
+}
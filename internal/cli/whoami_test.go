@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwin/fastmask/pkg/fastmail"
+)
+
+func Test_Whoami_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	sessionEndpoint := fastmail.APISessionEndpoint
+	fastmail.APISessionEndpoint = server.URL
+
+	defer func() { fastmail.APISessionEndpoint = sessionEndpoint }()
+
+	f := &fastmask{config: &config{AppName: appName, envCreds: &credentials{AccountID: "account-1", AccessToken: "token"}}}
+
+	cmd := f.loadWhoamiCmd()
+	cmd.SetArgs([]string{})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+
+	// An invalid token is an error, so the exit code is non-zero.
+	require.ErrorIs(t, cmd.ExecuteContext(context.TODO()), fastmail.ErrUnauthorized)
+}
@@ -16,9 +16,11 @@ type AuthResponse struct {
 	PrimaryAccounts  map[string]string      `json:"primaryAccounts,omitempty"`
 	AccessToken      string                 `json:"accessToken,omitempty"`
 	DisplayName      string                 `json:"displayName"`
+	Username         string                 `json:"username,omitempty"`
 	APIURL           string                 `json:"apiUrl,omitempty"`
 	DownloadURL      string                 `json:"downloadUrl,omitempty"`
 	UploadURL        string                 `json:"uploadUrl"`
+	EventSourceURL   string                 `json:"eventSourceUrl,omitempty"`
 	IsLoginBlocked   bool                   `json:"isLoginBlocked"`
 	State            string                 `json:"state"`
 	IsReseller       bool                   `json:"isReseller"`
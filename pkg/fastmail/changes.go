@@ -0,0 +1,64 @@
+package fastmail
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrorTypeCannotCalculateChanges is the MethodError type returned by MaskedEmail/changes when the
+// server can no longer calculate changes since the given state, all masked emails must be fetched again.
+const ErrorTypeCannotCalculateChanges = "cannotCalculateChanges"
+
+// MaskedEmailChangesPayload is the payload for the MaskedEmail/changes method.
+type MaskedEmailChangesPayload struct {
+	AccountID  string `json:"accountId,omitempty"`
+	SinceState string `json:"sinceState"`
+	MaxChanges int    `json:"maxChanges,omitempty"`
+}
+
+// MethodResponseMaskedEmailChanges are the IDs of masked emails created, updated and destroyed
+// since a state.
+type MethodResponseMaskedEmailChanges struct {
+	AccountID      string   `mapstructure:"accountId" json:"accountId,omitempty"`
+	OldState       string   `mapstructure:"oldState" json:"oldState,omitempty"`
+	NewState       string   `mapstructure:"newState" json:"newState,omitempty"`
+	HasMoreChanges bool     `mapstructure:"hasMoreChanges" json:"hasMoreChanges"`
+	Created        []string `mapstructure:"created" json:"created,omitempty"`
+	Updated        []string `mapstructure:"updated" json:"updated,omitempty"`
+	Destroyed      []string `mapstructure:"destroyed" json:"destroyed,omitempty"`
+	// SessionState is the session state of the response, see MethodResponseMaskedEmailGet.
+	SessionState string `mapstructure:"-" json:"-"`
+}
+
+// GetMaskedEmailChanges returns the IDs of the masked emails changed since sinceState, the state of
+// an earlier GetMaskedEmailList or GetMaskedEmailChanges. When HasMoreChanges is set, call again with
+// NewState for the rest. A MethodError of type ErrorTypeCannotCalculateChanges is returned when the
+// state is too old.
+func (c *Client) GetMaskedEmailChanges(ctx context.Context, sinceState string) (*MethodResponseMaskedEmailChanges, error) {
+	request := JMAPRequest{
+		Using: usingValueForMaskedEmail,
+		MethodCalls: []MethodCall{{
+			Name: "MaskedEmail/changes",
+			Payload: MaskedEmailChangesPayload{
+				AccountID:  c.creds.accountID,
+				SinceState: sinceState,
+			},
+			ID: "0",
+		}},
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	var result MethodResponseMaskedEmailChanges
+
+	if err := decodeMethodResponse(res, &result); err != nil {
+		return nil, err
+	}
+
+	result.SessionState = res.SessionState
+
+	return &result, nil
+}
@@ -0,0 +1,82 @@
+package fastmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Get_Masked_Email_Changes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("fastmask")
+	httpmock.ActivateNonDefault(client.httpC.GetClient()) // needed for to mock Resty.
+
+	client.SetTokenAuthCredentials("fakeAccountID", "fakeAccessToken")
+
+	ctx := context.TODO()
+
+	t.Run("Test Get Masked Email Changes", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		var requestBody map[string]interface{}
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"methodResponses": []interface{}{
+					[]interface{}{"MaskedEmail/changes", map[string]interface{}{
+						"accountId":      "fakeAccountID",
+						"oldState":       "41",
+						"newState":       "43",
+						"hasMoreChanges": false,
+						"created":        []string{"masked-3"},
+						"updated":        []string{"masked-1"},
+						"destroyed":      []string{"masked-2"},
+					}, "0"},
+				},
+				"sessionState": "session-1",
+			})
+		})
+
+		changes, err := client.GetMaskedEmailChanges(ctx, "41")
+		require.NoError(t, err)
+		require.Equal(t, "43", changes.NewState)
+		require.Equal(t, []string{"masked-3"}, changes.Created)
+		require.Equal(t, []string{"masked-1"}, changes.Updated)
+		require.Equal(t, []string{"masked-2"}, changes.Destroyed)
+		require.Equal(t, "session-1", changes.SessionState)
+
+		methodCall, ok := requestBody["methodCalls"].([]interface{})[0].([]interface{})
+		require.True(t, ok)
+		require.Equal(t, "MaskedEmail/changes", methodCall[0])
+		require.Equal(t, map[string]interface{}{"accountId": "fakeAccountID", "sinceState": "41"}, methodCall[1])
+	})
+
+	t.Run("Test Get Masked Email Changes - Cannot Calculate Changes", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		responder, err := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+			"methodResponses": []interface{}{
+				[]interface{}{"error", map[string]interface{}{"type": ErrorTypeCannotCalculateChanges}, "0"},
+			},
+		})
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, responder)
+
+		_, err = client.GetMaskedEmailChanges(ctx, "1")
+
+		var methodErr MethodError
+		require.ErrorAs(t, err, &methodErr)
+		require.Equal(t, ErrorTypeCannotCalculateChanges, methodErr.Type)
+	})
+}
@@ -2,6 +2,7 @@ package fastmail
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -13,22 +14,35 @@ var (
 	APIEndpoint = "https://api.fastmail.com/jmap/api/"
 	// APIAuthEndpoint is the Fastmail authentication endpoint.
 	APIAuthEndpoint = "https://www.fastmail.com/jmap/authenticate/"
+	// APISessionEndpoint is the Fastmail JMAP session endpoint.
+	APISessionEndpoint = "https://api.fastmail.com/jmap/session"
+)
+
+const (
+	// CapabilityCore is the JMAP core capability.
+	CapabilityCore = "urn:ietf:params:jmap:core"
+	// CapabilityMail is the JMAP mail capability.
+	CapabilityMail = "urn:ietf:params:jmap:mail"
+	// CapabilityMaskedEmail is the Fastmail masked email capability.
+	CapabilityMaskedEmail = "https://www.fastmail.com/dev/maskedemail"
 )
 
 var usingValueForMaskedEmail = []string{
-	"urn:ietf:params:jmap:core",
-	"https://www.fastmail.com/dev/maskedemail",
+	CapabilityCore,
+	CapabilityMaskedEmail,
 }
 
 type Client struct {
 	httpC  *resty.Client
 	config *ClientConfig
 	creds  *Credentials
+	ws     *webSocketConn
 }
 
 type ClientConfig struct {
 	AppName    string
 	APIBaseURL string
+	SessionURL string
 }
 
 type Credentials struct {
@@ -40,27 +54,32 @@ type Credentials struct {
 // not the same as the email address.
 func NewClient(appName string) *Client {
 	httpC := resty.New()
-	httpC.OnAfterResponse(func(c *resty.Client, r *resty.Response) error {
-		if r.StatusCode() == http.StatusOK || r.StatusCode() == http.StatusCreated {
-			return nil
-		}
-
-		if r.StatusCode() == http.StatusUnauthorized {
-			return ErrUnauthorized
-		}
-
-		return APIError{Msg: "unexpected response", Code: r.StatusCode(), Status: r.Status(), Detail: r.String()}
-	})
+	httpC.OnAfterResponse(checkResponse)
 
 	return &Client{
 		httpC: httpC,
 		config: &ClientConfig{
 			AppName:    appName,
 			APIBaseURL: APIEndpoint,
+			SessionURL: APISessionEndpoint,
 		},
 	}
 }
 
+// checkResponse returns an error for unsuccessful responses. resty does not call it for requests
+// with SetDoNotParseResponse, so streaming requests call it themselves.
+func checkResponse(_ *resty.Client, r *resty.Response) error {
+	if r.StatusCode() == http.StatusOK || r.StatusCode() == http.StatusCreated {
+		return nil
+	}
+
+	if r.StatusCode() == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+
+	return APIError{Msg: "unexpected response", Code: r.StatusCode(), Status: r.Status(), Detail: r.String()}
+}
+
 func (c *Client) SetTokenAuthCredentials(accountID, accessToken string) *Client {
 	c.creds = &Credentials{
 		accountID:   accountID,
@@ -72,7 +91,25 @@ func (c *Client) SetTokenAuthCredentials(accountID, accessToken string) *Client
 	return c
 }
 
+// AccountID returns the account ID of the client's credentials.
+func (c *Client) AccountID() string {
+	if c.creds == nil {
+		return ""
+	}
+
+	return c.creds.accountID
+}
+
 func (c *Client) sendRequest(ctx context.Context, r *JMAPRequest) (*JMAPResponse, error) {
+	if ws := c.webSocket(); ws != nil {
+		res, err := ws.send(ctx, r)
+		if !errors.Is(err, errWebSocketClosed) {
+			return res, err
+		}
+
+		// The connection was lost, fall back to HTTP.
+	}
+
 	var JMAPResponse JMAPResponse
 
 	request := c.httpC.R().SetBody(r)
@@ -0,0 +1,97 @@
+package fastmail
+
+import "sort"
+
+// Types of MaskedEmailChange.
+const (
+	ChangeCreated   = "created"
+	ChangeDestroyed = "destroyed"
+	ChangeUpdated   = "updated"
+)
+
+// Fields compared by DiffMaskedEmails, named as in the API.
+const (
+	FieldState       = "state"
+	FieldForDomain   = "forDomain"
+	FieldDescription = "description"
+	FieldURL         = "url"
+)
+
+// MaskedEmailChange is a difference of a masked email between two lists of masked emails.
+type MaskedEmailChange struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	// Fields are the changed fields of an updated masked email, eg. FieldState.
+	Fields []string `json:"fields,omitempty"`
+	// Old is the masked email before the change, nil if it was created.
+	Old *MaskedEmail `json:"old,omitempty"`
+	// New is the masked email after the change, nil if it was destroyed.
+	New *MaskedEmail `json:"new,omitempty"`
+}
+
+// DiffMaskedEmails returns the masked emails created, destroyed or updated between the old and new
+// list, sorted by email. Only user editable fields are compared, so a masked email receiving a
+// message is not a change.
+func DiffMaskedEmails(oldList, newList []MaskedEmail) []MaskedEmailChange {
+	oldByID := make(map[string]*MaskedEmail, len(oldList))
+	for i := range oldList {
+		oldByID[oldList[i].ID] = &oldList[i]
+	}
+
+	changes := []MaskedEmailChange{}
+
+	seen := make(map[string]bool, len(newList))
+
+	for i := range newList {
+		n := &newList[i]
+		seen[n.ID] = true
+
+		o, ok := oldByID[n.ID]
+		if !ok {
+			changes = append(changes, MaskedEmailChange{Type: ChangeCreated, ID: n.ID, Email: n.Email, New: n})
+
+			continue
+		}
+
+		if fields := changedFields(o, n); len(fields) > 0 {
+			changes = append(changes, MaskedEmailChange{Type: ChangeUpdated, ID: n.ID, Email: n.Email, Fields: fields, Old: o, New: n})
+		}
+	}
+
+	for i := range oldList {
+		if o := &oldList[i]; !seen[o.ID] {
+			changes = append(changes, MaskedEmailChange{Type: ChangeDestroyed, ID: o.ID, Email: o.Email, Old: o})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Email != changes[j].Email {
+			return changes[i].Email < changes[j].Email
+		}
+
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes
+}
+
+func changedFields(o, n *MaskedEmail) []string {
+	var fields []string
+
+	for _, field := range []struct {
+		name     string
+		old, new string
+	}{
+		{FieldState, o.State, n.State},
+		{FieldForDomain, o.ForDomain, n.ForDomain},
+		{FieldDescription, o.Description, n.Description},
+		{FieldURL, o.URL, n.URL},
+	} {
+		if field.old != field.new {
+			fields = append(fields, field.name)
+		}
+	}
+
+	return fields
+}
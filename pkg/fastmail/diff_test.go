@@ -0,0 +1,46 @@
+package fastmail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Diff_Masked_Emails(t *testing.T) {
+	oldList := []MaskedEmail{
+		{ID: "masked-1", Email: "a@fastmail.com", State: StateEnabled, Description: "Shop"},
+		{ID: "masked-2", Email: "b@fastmail.com", State: StateEnabled, LastMessageAt: "2022-01-01T00:00:00Z"},
+		{ID: "masked-3", Email: "c@fastmail.com", State: StateEnabled},
+	}
+
+	newList := []MaskedEmail{
+		{ID: "masked-4", Email: "d@fastmail.com", State: StatePending},
+		{ID: "masked-2", Email: "b@fastmail.com", State: StateEnabled, LastMessageAt: "2022-02-01T00:00:00Z"},
+		{ID: "masked-1", Email: "a@fastmail.com", State: StateDisabled, Description: "Old shop"},
+	}
+
+	changes := DiffMaskedEmails(oldList, newList)
+	require.Len(t, changes, 3)
+
+	require.Equal(t, ChangeUpdated, changes[0].Type)
+	require.Equal(t, "masked-1", changes[0].ID)
+	require.Equal(t, []string{FieldState, FieldDescription}, changes[0].Fields)
+	require.Equal(t, "Shop", changes[0].Old.Description)
+	require.Equal(t, "Old shop", changes[0].New.Description)
+
+	require.Equal(t, ChangeDestroyed, changes[1].Type)
+	require.Equal(t, "masked-3", changes[1].ID)
+	require.Nil(t, changes[1].New)
+
+	require.Equal(t, ChangeCreated, changes[2].Type)
+	require.Equal(t, "masked-4", changes[2].ID)
+	require.Nil(t, changes[2].Old)
+
+	require.Empty(t, DiffMaskedEmails(oldList, oldList))
+
+	// No changes is an empty list rather than null in JSON.
+	b, err := json.Marshal(DiffMaskedEmails(oldList, oldList))
+	require.NoError(t, err)
+	require.JSONEq(t, "[]", string(b))
+}
@@ -0,0 +1,245 @@
+package fastmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+var ErrNoMailAccount = errors.New("session has no mail account")
+
+var usingValueForMail = []string{
+	CapabilityCore,
+	CapabilityMail,
+}
+
+// emailProperties are the Email properties returned by QueryEmails.
+var emailProperties = []string{"id", "from", "to", "subject", "receivedAt", "mailboxIds"}
+
+// EmailAddress is a name and address of an email header, eg. From.
+type EmailAddress struct {
+	Name  string `json:"name,omitempty" mapstructure:"name"`
+	Email string `json:"email" mapstructure:"email"`
+}
+
+// Email is a message in a mail account, with the properties returned by QueryEmails.
+type Email struct {
+	ID         string          `json:"id" mapstructure:"id"`
+	From       []EmailAddress  `json:"from,omitempty" mapstructure:"from"`
+	To         []EmailAddress  `json:"to,omitempty" mapstructure:"to"`
+	Subject    string          `json:"subject,omitempty" mapstructure:"subject"`
+	ReceivedAt string          `json:"receivedAt,omitempty" mapstructure:"receivedAt"`
+	MailboxIDs map[string]bool `json:"mailboxIds,omitempty" mapstructure:"mailboxIds"`
+}
+
+// Sender returns the address of the first From header, or an empty string if there is none.
+func (e *Email) Sender() string {
+	if len(e.From) == 0 {
+		return ""
+	}
+
+	return e.From[0].Email
+}
+
+// deliveredToHeader is the header Fastmail adds with the address a message was delivered to,
+// which is the only record of recipients in Bcc.
+const deliveredToHeader = "X-Delivered-To"
+
+// EmailFilter is an Email/query filter condition, empty fields are not filtered on.
+type EmailFilter struct {
+	InMailbox string `json:"inMailbox,omitempty"`
+	// To, Cc, Bcc and From match emails with the text in the header, eg. an address or '@example.com'.
+	To   string `json:"to,omitempty"`
+	Cc   string `json:"cc,omitempty"`
+	Bcc  string `json:"bcc,omitempty"`
+	From string `json:"from,omitempty"`
+	// Header matches emails with the header, and if given the text in its value.
+	Header []string `json:"header,omitempty"`
+	// After and Before are UTC dates, eg. '2022-06-01T00:00:00Z', matched against receivedAt.
+	After  string `json:"after,omitempty"`
+	Before string `json:"before,omitempty"`
+}
+
+// EmailFilterOperator combines filter conditions, Operator is one of AND, OR or NOT.
+type EmailFilterOperator struct {
+	Operator   string        `json:"operator"`
+	Conditions []interface{} `json:"conditions"`
+}
+
+// EmailQuery selects emails for QueryEmails.
+type EmailQuery struct {
+	Filter EmailFilter
+	// Recipient matches emails delivered to the address, whether it is in the To, Cc or Bcc header,
+	// in addition to Filter.
+	Recipient string
+	// Ascending returns the oldest emails first, otherwise the newest are first.
+	Ascending bool
+	// Limit is the maximum number of emails returned, 0 for the server's default.
+	Limit int
+}
+
+// EmailQueryResult are the emails matching an EmailQuery.
+type EmailQueryResult struct {
+	Emails []Email
+	// Total is the number of matching emails, which may be more than returned.
+	Total int
+}
+
+// EmailComparator sorts Email/query results.
+type EmailComparator struct {
+	Property    string `json:"property"`
+	IsAscending bool   `json:"isAscending"`
+}
+
+// EmailQueryPayload is the payload for the Email/query method.
+type EmailQueryPayload struct {
+	AccountID      string            `json:"accountId"`
+	Filter         interface{}       `json:"filter"`
+	Sort           []EmailComparator `json:"sort,omitempty"`
+	Limit          int               `json:"limit,omitempty"`
+	CalculateTotal bool              `json:"calculateTotal"`
+}
+
+// ResultReference refers to the result of an earlier method call in the same request.
+type ResultReference struct {
+	ResultOf string `json:"resultOf"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+}
+
+// EmailGetPayload is the payload for the Email/get method, with the IDs of an Email/query result.
+type EmailGetPayload struct {
+	AccountID  string          `json:"accountId"`
+	IDsRef     ResultReference `json:"#ids"`
+	Properties []string        `json:"properties"`
+}
+
+type MethodResponseEmailQuery struct {
+	AccountID string   `mapstructure:"accountId"`
+	IDs       []string `mapstructure:"ids"`
+	Total     int      `mapstructure:"total"`
+}
+
+type MethodResponseEmailGet struct {
+	AccountID string  `mapstructure:"accountId"`
+	List      []Email `mapstructure:"list"`
+}
+
+// filter returns the Email/query filter of the query.
+func (q *EmailQuery) filter() interface{} {
+	if q.Recipient == "" {
+		return q.Filter
+	}
+
+	return EmailFilterOperator{
+		Operator: "AND",
+		Conditions: []interface{}{
+			q.Filter,
+			EmailFilterOperator{
+				Operator: "OR",
+				Conditions: []interface{}{
+					EmailFilter{To: q.Recipient},
+					EmailFilter{Cc: q.Recipient},
+					EmailFilter{Bcc: q.Recipient},
+					EmailFilter{Header: []string{deliveredToHeader, q.Recipient}},
+				},
+			},
+		},
+	}
+}
+
+// MailAccountID returns the primary mail account of the session, which may differ from the masked
+// email account.
+func (c *Client) MailAccountID(ctx context.Context) (string, error) {
+	session, err := c.GetSession(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	accountID, ok := session.PrimaryAccounts[CapabilityMail]
+	if !ok || accountID == "" {
+		return "", ErrNoMailAccount
+	}
+
+	return accountID, nil
+}
+
+// QueryEmails returns the emails matching each query, sorted by receivedAt, in a single request.
+// Servers limit the calls in a request, each query is two calls, so callers with many queries
+// should send them in batches.
+func (c *Client) QueryEmails(ctx context.Context, accountID string, queries ...EmailQuery) ([]EmailQueryResult, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	request := JMAPRequest{Using: usingValueForMail}
+
+	for i, query := range queries {
+		queryID, getID := "q"+strconv.Itoa(i), "g"+strconv.Itoa(i)
+
+		request.MethodCalls = append(request.MethodCalls,
+			MethodCall{
+				Name: "Email/query",
+				Payload: EmailQueryPayload{
+					AccountID:      accountID,
+					Filter:         query.filter(),
+					Sort:           []EmailComparator{{Property: "receivedAt", IsAscending: query.Ascending}},
+					Limit:          query.Limit,
+					CalculateTotal: true,
+				},
+				ID: queryID,
+			},
+			MethodCall{
+				Name: "Email/get",
+				Payload: EmailGetPayload{
+					AccountID:  accountID,
+					IDsRef:     ResultReference{ResultOf: queryID, Name: "Email/query", Path: "/ids"},
+					Properties: emailProperties,
+				},
+				ID: getID,
+			},
+		)
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	// nolint:gomnd // two method responses per query.
+	if len(res.MethodResponses) != 2*len(queries) {
+		return nil, MethodResponseError{len(res.MethodResponses), 2 * len(queries)}
+	}
+
+	results := make([]EmailQueryResult, len(queries))
+
+	for i := range queries {
+		var (
+			query MethodResponseEmailQuery
+			get   MethodResponseEmailGet
+		)
+
+		if err := decodeMethodResponseAt(res, 2*i, &query); err != nil {
+			return nil, err
+		}
+
+		if err := decodeMethodResponseAt(res, 2*i+1, &get); err != nil {
+			return nil, err
+		}
+
+		// Email/get does not keep the order of the query, receivedAt dates are UTC so sort as strings.
+		sort.SliceStable(get.List, func(a, b int) bool {
+			if queries[i].Ascending {
+				return get.List[a].ReceivedAt < get.List[b].ReceivedAt
+			}
+
+			return get.List[a].ReceivedAt > get.List[b].ReceivedAt
+		})
+
+		results[i] = EmailQueryResult{Emails: get.List, Total: query.Total}
+	}
+
+	return results, nil
+}
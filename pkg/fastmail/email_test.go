@@ -0,0 +1,104 @@
+package fastmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Query_Emails(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("fastmask")
+	httpmock.ActivateNonDefault(client.httpC.GetClient()) // needed for to mock Resty.
+
+	client.SetTokenAuthCredentials(fakeAccountID, fakeAccessToken)
+
+	ctx := context.TODO()
+
+	t.Run("Mail Account ID", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		sessionResponder, err := httpmock.NewJsonResponder(http.StatusOK, httpmock.File("examples/session_response.json"))
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodGet, APISessionEndpoint, sessionResponder)
+
+		accountID, err := client.MailAccountID(ctx)
+		require.NoError(t, err)
+		require.Equal(t, fakeAccountID, accountID)
+	})
+
+	t.Run("Query Emails", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		var body struct {
+			Using       []string        `json:"using"`
+			MethodCalls [][]interface{} `json:"methodCalls"`
+		}
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"methodResponses": []interface{}{
+					[]interface{}{"Email/query", map[string]interface{}{"accountId": "mail-1", "ids": []string{"e2", "e1"}, "total": 7}, "q0"},
+					[]interface{}{"Email/get", map[string]interface{}{"accountId": "mail-1", "list": []interface{}{
+						map[string]interface{}{"id": "e1", "from": []interface{}{map[string]interface{}{"name": "Shop", "email": "news@shop.example.com"}}, "receivedAt": "2022-05-01T00:00:00Z"},
+						map[string]interface{}{"id": "e2", "from": nil, "receivedAt": "2022-06-01T00:00:00Z", "mailboxIds": map[string]bool{"junk": true}},
+					}}, "g0"},
+					[]interface{}{"Email/query", map[string]interface{}{"accountId": "mail-1", "ids": []string{}, "total": 0}, "q1"},
+					[]interface{}{"Email/get", map[string]interface{}{"accountId": "mail-1", "list": []interface{}{}}, "g1"},
+				},
+			})
+		})
+
+		results, err := client.QueryEmails(ctx, "mail-1",
+			EmailQuery{Filter: EmailFilter{To: "one@fastmail.com"}, Limit: 10},
+			EmailQuery{Filter: EmailFilter{After: "2022-01-01T00:00:00Z"}, Recipient: "two@fastmail.com", Ascending: true},
+		)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		require.Equal(t, 7, results[0].Total)
+		require.Equal(t, "e2", results[0].Emails[0].ID, "newest first")
+		require.Empty(t, results[0].Emails[0].Sender())
+		require.True(t, results[0].Emails[0].MailboxIDs["junk"])
+		require.Equal(t, "news@shop.example.com", results[0].Emails[1].Sender())
+		require.Empty(t, results[1].Emails)
+
+		require.Equal(t, []string{CapabilityCore, CapabilityMail}, body.Using)
+		require.Len(t, body.MethodCalls, 4)
+		require.Equal(t, []interface{}{"Email/query", map[string]interface{}{
+			"accountId":      "mail-1",
+			"filter":         map[string]interface{}{"to": "one@fastmail.com"},
+			"sort":           []interface{}{map[string]interface{}{"property": "receivedAt", "isAscending": false}},
+			"limit":          float64(10),
+			"calculateTotal": true,
+		}, "q0"}, body.MethodCalls[0])
+		require.Equal(t, map[string]interface{}{
+			"operator": "AND",
+			"conditions": []interface{}{
+				map[string]interface{}{"after": "2022-01-01T00:00:00Z"},
+				map[string]interface{}{
+					"operator": "OR",
+					"conditions": []interface{}{
+						map[string]interface{}{"to": "two@fastmail.com"},
+						map[string]interface{}{"cc": "two@fastmail.com"},
+						map[string]interface{}{"bcc": "two@fastmail.com"},
+						map[string]interface{}{"header": []interface{}{"X-Delivered-To", "two@fastmail.com"}},
+					},
+				},
+			},
+		}, body.MethodCalls[2][1].(map[string]interface{})["filter"], "recipients in Cc, Bcc or only the delivery header match")
+		require.Equal(t, map[string]interface{}{"resultOf": "q1", "name": "Email/query", "path": "/ids"},
+			body.MethodCalls[3][1].(map[string]interface{})["#ids"])
+	})
+}
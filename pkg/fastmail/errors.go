@@ -3,6 +3,8 @@ package fastmail
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 var (
@@ -31,3 +33,32 @@ type MethodResponseError struct {
 func (m MethodResponseError) Error() string {
 	return fmt.Sprintf("fastmail api returned unexpected method response , have method %d responses and expected: %d", m.Actual, m.Expected)
 }
+
+// MethodError is returned when the API responds to a method call with an error, eg. 'invalidArguments'.
+type MethodError struct {
+	Type        string
+	Description string
+}
+
+func (m MethodError) Error() string {
+	return fmt.Sprintf("fastmail api method error: '%s', description: '%s'", m.Type, m.Description)
+}
+
+// SetErrors are the masked emails a MaskedEmail/set call failed for, keyed by ID.
+type SetErrors map[string]SetError
+
+func (s SetErrors) Error() string {
+	ids := make([]string, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	failed := make([]string, 0, len(ids))
+	for _, id := range ids {
+		failed = append(failed, fmt.Sprintf("%s: %s %s", id, s[id].Type, s[id].Description))
+	}
+
+	return fmt.Sprintf("fastmail api failed to set %d masked emails: %s", len(s), strings.Join(failed, "; "))
+}
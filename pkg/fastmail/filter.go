@@ -0,0 +1,259 @@
+package fastmail
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Masked email states.
+const (
+	StateEnabled  = "enabled"
+	StateDisabled = "disabled"
+	StatePending  = "pending"
+	StateDeleted  = "deleted"
+)
+
+// Fields masked emails can be sorted by, prefix with '-' for descending order.
+const (
+	SortByCreatedAt     = "created"
+	SortByLastMessageAt = "last-message"
+	SortByDomain        = "domain"
+	SortByEmail         = "email"
+	SortByState         = "state"
+)
+
+var ErrInvalidSortField = errors.New("invalid sort field")
+
+// MaskedEmailFilter selects masked emails, fields left at their zero value match every masked email.
+type MaskedEmailFilter struct {
+	// States matches masked emails in any of the given states.
+	States []string
+	// Domain is a glob pattern, eg. '*.example.com', matched against the domain and its hostname.
+	Domain string
+	// DomainRegexp is matched against the domain and its hostname, it is used in addition to Domain.
+	DomainRegexp *regexp.Regexp
+	// CreatedBy matches masked emails created by any of the given apps, case-insensitive.
+	CreatedBy []string
+	// Since matches masked emails created at or after the given time.
+	Since time.Time
+	// Until matches masked emails created before the given time.
+	Until time.Time
+	// InactiveFor matches masked emails that have not received a message within the duration,
+	// masked emails that never received a message are compared by when they were created.
+	InactiveFor time.Duration
+	// Now is the time InactiveFor is relative to, defaults to the current time.
+	Now time.Time
+}
+
+// Match returns true if the masked email matches all conditions of the filter.
+func (f *MaskedEmailFilter) Match(m *MaskedEmail) bool {
+	return f.matchState(m) &&
+		f.matchDomain(m) &&
+		f.matchCreatedBy(m) &&
+		f.matchCreatedAt(m) &&
+		f.matchInactive(m)
+}
+
+// Filter returns the masked emails matching the filter.
+func (f *MaskedEmailFilter) Filter(maskedEmails []MaskedEmail) []MaskedEmail {
+	result := make([]MaskedEmail, 0, len(maskedEmails))
+
+	for i := range maskedEmails {
+		if f.Match(&maskedEmails[i]) {
+			result = append(result, maskedEmails[i])
+		}
+	}
+
+	return result
+}
+
+func (f *MaskedEmailFilter) matchState(m *MaskedEmail) bool {
+	if len(f.States) == 0 {
+		return true
+	}
+
+	for _, state := range f.States {
+		if strings.EqualFold(state, m.State) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *MaskedEmailFilter) matchDomain(m *MaskedEmail) bool {
+	if f.DomainRegexp != nil && !f.DomainRegexp.MatchString(m.ForDomain) && !f.DomainRegexp.MatchString(m.Hostname()) {
+		return false
+	}
+
+	if f.Domain == "" {
+		return true
+	}
+
+	pattern := strings.ToLower(f.Domain)
+
+	for _, candidate := range []string{strings.ToLower(m.ForDomain), m.Hostname()} {
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *MaskedEmailFilter) matchCreatedBy(m *MaskedEmail) bool {
+	if len(f.CreatedBy) == 0 {
+		return true
+	}
+
+	for _, createdBy := range f.CreatedBy {
+		if strings.EqualFold(createdBy, m.CreatedBy) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *MaskedEmailFilter) matchCreatedAt(m *MaskedEmail) bool {
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+
+	createdAt, ok := m.CreatedAtTime()
+	if !ok {
+		return false
+	}
+
+	if !f.Since.IsZero() && createdAt.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && !createdAt.Before(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+func (f *MaskedEmailFilter) matchInactive(m *MaskedEmail) bool {
+	if f.InactiveFor <= 0 {
+		return true
+	}
+
+	now := f.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	lastActive, ok := m.LastMessageAtTime()
+	if !ok {
+		if lastActive, ok = m.CreatedAtTime(); !ok {
+			return false
+		}
+	}
+
+	return lastActive.Before(now.Add(-f.InactiveFor))
+}
+
+// Hostname returns the lowercase hostname of the masked email domain, which may be stored
+// as a URL, eg. 'https://www.example.com' returns 'www.example.com'.
+func (m *MaskedEmail) Hostname() string {
+	domain := strings.ToLower(strings.TrimSpace(m.ForDomain))
+
+	if !strings.Contains(domain, "://") {
+		domain = "//" + domain
+	}
+
+	u, err := url.Parse(domain)
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+// CreatedAtTime returns CreatedAt parsed, ok is false if it is not set or invalid.
+func (m *MaskedEmail) CreatedAtTime() (t time.Time, ok bool) {
+	return parseTime(m.CreatedAt)
+}
+
+// LastMessageAtTime returns LastMessageAt parsed, ok is false if no message was received.
+func (m *MaskedEmail) LastMessageAtTime() (t time.Time, ok bool) {
+	return parseTime(m.LastMessageAt)
+}
+
+func parseTime(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// SortMaskedEmails sorts masked emails in place by the given field, eg. 'created' or '-created'
+// for descending order. Masked emails without a value for the field are sorted last.
+func SortMaskedEmails(maskedEmails []MaskedEmail, field string) error {
+	desc := strings.HasPrefix(field, "-")
+
+	var key func(m *MaskedEmail) string
+
+	switch strings.TrimPrefix(field, "-") {
+	case SortByCreatedAt:
+		key = timeSortKey((*MaskedEmail).CreatedAtTime)
+	case SortByLastMessageAt:
+		key = timeSortKey((*MaskedEmail).LastMessageAtTime)
+	case SortByDomain:
+		key = (*MaskedEmail).Hostname
+	case SortByEmail:
+		key = func(m *MaskedEmail) string { return strings.ToLower(m.Email) }
+	case SortByState:
+		key = func(m *MaskedEmail) string { return m.State }
+	default:
+		return fmt.Errorf("%w: '%s', expected one of: %s", ErrInvalidSortField, field, strings.Join(SortFields(), ", "))
+	}
+
+	sort.SliceStable(maskedEmails, func(i, j int) bool {
+		a, b := key(&maskedEmails[i]), key(&maskedEmails[j])
+
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+
+		if desc {
+			return a > b
+		}
+
+		return a < b
+	})
+
+	return nil
+}
+
+// SortFields returns the fields masked emails can be sorted by.
+func SortFields() []string {
+	return []string{SortByCreatedAt, SortByLastMessageAt, SortByDomain, SortByEmail, SortByState}
+}
+
+// timeSortKey returns a sort key function for a time field, formatted so keys sort in time order.
+func timeSortKey(value func(*MaskedEmail) (time.Time, bool)) func(*MaskedEmail) string {
+	return func(m *MaskedEmail) string {
+		t, ok := value(m)
+		if !ok {
+			return ""
+		}
+
+		return t.UTC().Format("2006-01-02T15:04:05.000000000")
+	}
+}
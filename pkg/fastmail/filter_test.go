@@ -0,0 +1,88 @@
+package fastmail
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Masked_Email_Filter(t *testing.T) {
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	maskedEmails := []MaskedEmail{
+		{
+			ID: "masked-1", State: StateEnabled, ForDomain: "https://www.example.com", CreatedBy: "fastmask",
+			CreatedAt: "2022-04-20T12:00:00Z", LastMessageAt: "2022-06-30T08:30:00Z",
+		},
+		{
+			ID: "masked-2", State: StateDisabled, ForDomain: "https://shop.example.org", CreatedBy: "1Password",
+			CreatedAt: "2021-01-02T03:04:05Z",
+		},
+		{
+			ID: "masked-3", State: StatePending, ForDomain: "news.example.net", CreatedBy: "fastmask",
+			CreatedAt: "2022-06-01T00:00:00Z",
+		},
+	}
+
+	ids := func(list []MaskedEmail) []string {
+		result := make([]string, 0, len(list))
+		for i := range list {
+			result = append(result, list[i].ID)
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		filter   MaskedEmailFilter
+		expected []string
+	}{
+		{name: "Empty Filter", filter: MaskedEmailFilter{}, expected: []string{"masked-1", "masked-2", "masked-3"}},
+		{name: "States", filter: MaskedEmailFilter{States: []string{"enabled", "PENDING"}}, expected: []string{"masked-1", "masked-3"}},
+		{name: "Domain Glob", filter: MaskedEmailFilter{Domain: "*.example.org"}, expected: []string{"masked-2"}},
+		{name: "Domain Exact", filter: MaskedEmailFilter{Domain: "news.example.net"}, expected: []string{"masked-3"}},
+		{name: "Domain Regexp", filter: MaskedEmailFilter{DomainRegexp: regexp.MustCompile(`example\.(com|net)$`)}, expected: []string{"masked-1", "masked-3"}},
+		{name: "Domain Regexp Hostname", filter: MaskedEmailFilter{DomainRegexp: regexp.MustCompile(`^shop\.example\.org$`)}, expected: []string{"masked-2"}},
+		{name: "Created By", filter: MaskedEmailFilter{CreatedBy: []string{"1password"}}, expected: []string{"masked-2"}},
+		{name: "Since", filter: MaskedEmailFilter{Since: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}, expected: []string{"masked-1", "masked-3"}},
+		{name: "Until", filter: MaskedEmailFilter{Until: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)}, expected: []string{"masked-1", "masked-2"}},
+		{name: "Inactive For", filter: MaskedEmailFilter{InactiveFor: 180 * 24 * time.Hour, Now: now}, expected: []string{"masked-2"}},
+		{name: "Inactive For - Never Received", filter: MaskedEmailFilter{InactiveFor: 7 * 24 * time.Hour, Now: now}, expected: []string{"masked-2", "masked-3"}},
+		{name: "Combined", filter: MaskedEmailFilter{States: []string{StateEnabled}, CreatedBy: []string{"fastmask"}}, expected: []string{"masked-1"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, ids(tt.filter.Filter(maskedEmails)))
+		})
+	}
+
+	t.Run("Sort", func(t *testing.T) {
+		list := append([]MaskedEmail(nil), maskedEmails...)
+
+		require.NoError(t, SortMaskedEmails(list, "created"))
+		require.Equal(t, []string{"masked-2", "masked-1", "masked-3"}, ids(list))
+
+		require.NoError(t, SortMaskedEmails(list, "-created"))
+		require.Equal(t, []string{"masked-3", "masked-1", "masked-2"}, ids(list))
+
+		require.NoError(t, SortMaskedEmails(list, "-last-message"))
+		require.Equal(t, "masked-1", list[0].ID, "masked emails without messages sort last")
+
+		require.NoError(t, SortMaskedEmails(list, "domain"))
+		require.Equal(t, []string{"masked-3", "masked-2", "masked-1"}, ids(list))
+
+		require.ErrorIs(t, SortMaskedEmails(list, "size"), ErrInvalidSortField)
+	})
+
+	t.Run("Hostname", func(t *testing.T) {
+		require.Equal(t, "www.example.com", (&MaskedEmail{ForDomain: "https://www.example.com/login"}).Hostname())
+		require.Equal(t, "example.com", (&MaskedEmail{ForDomain: "Example.com"}).Hostname())
+		require.Empty(t, (&MaskedEmail{}).Hostname())
+	})
+}
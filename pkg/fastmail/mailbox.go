@@ -0,0 +1,76 @@
+package fastmail
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mailbox roles, see RFC 8621 section 2.
+const (
+	MailboxRoleInbox = "inbox"
+	MailboxRoleJunk  = "junk"
+	MailboxRoleTrash = "trash"
+)
+
+// mailboxProperties are the Mailbox properties returned by GetMailboxes.
+var mailboxProperties = []string{"id", "name", "role", "totalEmails"}
+
+// Mailbox is a folder of a mail account, which may have a role such as junk.
+type Mailbox struct {
+	ID          string `json:"id" mapstructure:"id"`
+	Name        string `json:"name" mapstructure:"name"`
+	Role        string `json:"role,omitempty" mapstructure:"role"`
+	TotalEmails int    `json:"totalEmails" mapstructure:"totalEmails"`
+}
+
+// MailboxGetPayload is the payload for the Mailbox/get method, nil IDs gets all mailboxes.
+type MailboxGetPayload struct {
+	AccountID  string   `json:"accountId"`
+	IDs        []string `json:"ids"`
+	Properties []string `json:"properties"`
+}
+
+type MethodResponseMailboxGet struct {
+	AccountID string    `mapstructure:"accountId"`
+	State     string    `mapstructure:"state"`
+	List      []Mailbox `mapstructure:"list"`
+}
+
+// GetMailboxes returns all mailboxes of the mail account, see MailAccountID.
+func (c *Client) GetMailboxes(ctx context.Context, accountID string) ([]Mailbox, error) {
+	request := JMAPRequest{
+		Using: usingValueForMail,
+		MethodCalls: []MethodCall{{
+			Name: "Mailbox/get",
+			Payload: MailboxGetPayload{
+				AccountID:  accountID,
+				Properties: mailboxProperties,
+			},
+			ID: "0",
+		}},
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	var result MethodResponseMailboxGet
+
+	if err := decodeMethodResponse(res, &result); err != nil {
+		return nil, err
+	}
+
+	return result.List, nil
+}
+
+// MailboxByRole returns the mailbox with the role, ok is false if there is none.
+func MailboxByRole(mailboxes []Mailbox, role string) (mailbox Mailbox, ok bool) {
+	for _, m := range mailboxes {
+		if m.Role == role {
+			return m, true
+		}
+	}
+
+	return Mailbox{}, false
+}
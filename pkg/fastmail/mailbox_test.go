@@ -0,0 +1,48 @@
+package fastmail
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Get_Mailboxes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("fastmask")
+	httpmock.ActivateNonDefault(client.httpC.GetClient()) // needed for to mock Resty.
+
+	client.SetTokenAuthCredentials(fakeAccountID, fakeAccessToken)
+
+	responder, err := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+		"methodResponses": []interface{}{
+			[]interface{}{"Mailbox/get", map[string]interface{}{
+				"accountId": "mail-1",
+				"state":     "1",
+				"list": []interface{}{
+					map[string]interface{}{"id": "mb-inbox", "name": "Inbox", "role": "inbox", "totalEmails": 120},
+					map[string]interface{}{"id": "mb-junk", "name": "Spam", "role": "junk", "totalEmails": 4},
+					map[string]interface{}{"id": "mb-receipts", "name": "Receipts", "role": nil, "totalEmails": 9},
+				},
+			}, "0"},
+		},
+	})
+	require.NoError(t, err)
+
+	httpmock.RegisterResponder(http.MethodPost, APIEndpoint, responder)
+
+	mailboxes, err := client.GetMailboxes(context.TODO(), "mail-1")
+	require.NoError(t, err)
+	require.Len(t, mailboxes, 3)
+
+	junk, ok := MailboxByRole(mailboxes, MailboxRoleJunk)
+	require.True(t, ok)
+	require.Equal(t, Mailbox{ID: "mb-junk", Name: "Spam", Role: MailboxRoleJunk, TotalEmails: 4}, junk)
+
+	_, ok = MailboxByRole(mailboxes, MailboxRoleTrash)
+	require.False(t, ok)
+}
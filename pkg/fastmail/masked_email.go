@@ -3,8 +3,6 @@ package fastmail
 import (
 	"context"
 	"fmt"
-
-	"github.com/mitchellh/mapstructure"
 )
 
 // MaskedEmail represents a Fastmail masked email.
@@ -29,6 +27,13 @@ type MaskedEmailPayload struct {
 	Destroy   []string                `json:"destroy,omitempty"`
 }
 
+// MaskedEmailGetPayload is the payload for the MaskedEmail/get method, all masked emails are
+// returned when IDs is empty.
+type MaskedEmailGetPayload struct {
+	AccountID string   `json:"accountId,omitempty"`
+	IDs       []string `json:"ids,omitempty"`
+}
+
 // CreateMaskedEmail creates a new masked email for the given forDomain domain.
 // If `enabled` is set to false, will only create a pending email and needs to be confirmed before it's usable.
 func (c *Client) CreateMaskedEmail(ctx context.Context, maskedEmail *MaskedEmail, enabled bool) (*MaskedEmail, error) {
@@ -52,19 +57,11 @@ func (c *Client) CreateMaskedEmail(ctx context.Context, maskedEmail *MaskedEmail
 	if err != nil {
 		return nil, fmt.Errorf("send request error: %w", err)
 	}
-	// nolint:gomnd // ignore here.
-	if len(res.MethodResponses) != 1 {
-		return nil, MethodResponseError{len(res.MethodResponses), 1}
-	}
-	// nolint:gomnd // ignore here.
-	if len(res.MethodResponses[0]) != 3 {
-		return nil, MethodResponseError{len(res.MethodResponses[0]), 3}
-	}
 
 	var payload MethodResponseMaskedEmailSet
 
-	if err := mapstructure.Decode(res.MethodResponses[0][1], &payload); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	if err := decodeMethodResponse(res, &payload); err != nil {
+		return nil, err
 	}
 
 	created, err := payload.GetCreatedItem()
@@ -75,7 +72,136 @@ func (c *Client) CreateMaskedEmail(ctx context.Context, maskedEmail *MaskedEmail
 	return &created, nil
 }
 
-// DeleteMaskedEmails deletes the given masked emails by ID.
+// CreateMaskedEmails creates the given masked emails in a single request, keyed by a client chosen
+// creation ID of letters, digits, '-' and '_'. The created masked emails are returned by creation ID,
+// along with a SetErrors error keyed by creation ID if any could not be created, so partial success
+// can be recorded by the caller.
+func (c *Client) CreateMaskedEmails(ctx context.Context, maskedEmails map[string]*MaskedEmail) (map[string]MaskedEmail, error) {
+	request := JMAPRequest{
+		Using: usingValueForMaskedEmail,
+		MethodCalls: []MethodCall{{
+			Name: "MaskedEmail/set",
+			Payload: MaskedEmailPayload{
+				AccountID: c.creds.accountID,
+				Create:    maskedEmails,
+			},
+			ID: "0",
+		}},
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	var payload MethodResponseMaskedEmailSet
+
+	if err := decodeMethodResponse(res, &payload); err != nil {
+		return nil, err
+	}
+
+	return payload.Created, payload.Err()
+}
+
+// GetMaskedEmails returns the masked emails with the given IDs, or all masked emails if no IDs are given.
+func (c *Client) GetMaskedEmails(ctx context.Context, ids ...string) ([]MaskedEmail, error) {
+	result, err := c.GetMaskedEmailList(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.List, nil
+}
+
+// GetMaskedEmailList is like GetMaskedEmails but returns the full response, including the state
+// string, which changes whenever any masked email changes, and the IDs that were not found.
+func (c *Client) GetMaskedEmailList(ctx context.Context, ids ...string) (*MethodResponseMaskedEmailGet, error) {
+	request := JMAPRequest{
+		Using: usingValueForMaskedEmail,
+		MethodCalls: []MethodCall{{
+			Name: "MaskedEmail/get",
+			Payload: MaskedEmailGetPayload{
+				AccountID: c.creds.accountID,
+				IDs:       ids,
+			},
+			ID: "0",
+		}},
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	var result MethodResponseMaskedEmailGet
+
+	if err := decodeMethodResponse(res, &result); err != nil {
+		return nil, err
+	}
+
+	result.SessionState = res.SessionState
+
+	return &result, nil
+}
+
+// MaskedEmailUpdate is a partial update of a masked email, nil fields are left unchanged.
+type MaskedEmailUpdate struct {
+	State       *string `json:"state,omitempty"`
+	ForDomain   *string `json:"forDomain,omitempty"`
+	Description *string `json:"description,omitempty"`
+	URL         *string `json:"url,omitempty"`
+}
+
+// MaskedEmailUpdatePayload is the payload for updating masked emails with the MaskedEmail/set method.
+type MaskedEmailUpdatePayload struct {
+	AccountID string                       `json:"accountId,omitempty"`
+	Update    map[string]MaskedEmailUpdate `json:"update"`
+}
+
+// UpdateMaskedEmails applies the updates, keyed by masked email ID, in a single request. A
+// SetErrors error is returned listing any masked emails that could not be updated.
+func (c *Client) UpdateMaskedEmails(ctx context.Context, updates map[string]MaskedEmailUpdate) error {
+	request := JMAPRequest{
+		Using: usingValueForMaskedEmail,
+		MethodCalls: []MethodCall{{
+			Name: "MaskedEmail/set",
+			Payload: MaskedEmailUpdatePayload{
+				AccountID: c.creds.accountID,
+				Update:    updates,
+			},
+			ID: "0",
+		}},
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return fmt.Errorf("send request error: %w", err)
+	}
+
+	var payload MethodResponseMaskedEmailSet
+
+	if err := decodeMethodResponse(res, &payload); err != nil {
+		return err
+	}
+
+	return payload.Err()
+}
+
+// SetMaskedEmailsState sets the state of the given masked emails by ID, eg. StateDeleted to
+// soft delete them so they can be restored later.
+func (c *Client) SetMaskedEmailsState(ctx context.Context, state string, ids ...string) error {
+	updates := make(map[string]MaskedEmailUpdate, len(ids))
+
+	for _, id := range ids {
+		s := state
+		updates[id] = MaskedEmailUpdate{State: &s}
+	}
+
+	return c.UpdateMaskedEmails(ctx, updates)
+}
+
+// DeleteMaskedEmails permanently destroys the given masked emails by ID, use SetMaskedEmailsState
+// with StateDeleted for a recoverable delete.
 func (c *Client) DeleteMaskedEmails(ctx context.Context, ids ...string) error {
 	request := JMAPRequest{
 		Using: usingValueForMaskedEmail,
@@ -89,10 +215,16 @@ func (c *Client) DeleteMaskedEmails(ctx context.Context, ids ...string) error {
 		}},
 	}
 
-	_, err := c.sendRequest(ctx, &request)
+	res, err := c.sendRequest(ctx, &request)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	var payload MethodResponseMaskedEmailSet
+
+	if err := decodeMethodResponse(res, &payload); err != nil {
+		return err
+	}
+
+	return payload.Err()
 }
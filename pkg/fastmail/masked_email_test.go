@@ -2,6 +2,7 @@ package fastmail
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -55,4 +56,138 @@ func Test_Masked_Email(t *testing.T) {
 		require.Nil(t, result)
 		require.ErrorIs(t, err, ErrUnauthorized)
 	})
+
+	t.Run("Test Get Masked Emails", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		getResponder, err := httpmock.NewJsonResponder(http.StatusOK, httpmock.File("examples/get_masked_response.json"))
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, getResponder)
+
+		result, err := client.GetMaskedEmails(ctx)
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+		require.Equal(t, "masked-12345678", result[0].ID)
+		require.Equal(t, "test.example1234@fastmail.com", result[0].Email)
+		require.Equal(t, "2022-05-01T08:30:00Z", result[0].LastMessageAt)
+		require.Empty(t, result[1].LastMessageAt)
+	})
+
+	t.Run("Test Get Masked Email List", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		getResponder, err := httpmock.NewJsonResponder(http.StatusOK, httpmock.File("examples/get_masked_response.json"))
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, getResponder)
+
+		result, err := client.GetMaskedEmailList(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "42", result.State)
+		require.Equal(t, "april-0;p-19;vfs-0", result.SessionState)
+		require.Len(t, result.List, 3)
+	})
+
+	t.Run("Test Get Masked Emails - Method Error", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		errorResponder, err := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+			"methodResponses": []interface{}{
+				[]interface{}{"error", map[string]interface{}{"type": "accountNotFound"}, "0"},
+			},
+		})
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, errorResponder)
+
+		result, err := client.GetMaskedEmails(ctx, "masked-12345678")
+		require.Nil(t, result)
+
+		var methodErr MethodError
+		require.ErrorAs(t, err, &methodErr)
+		require.Equal(t, "accountNotFound", methodErr.Type)
+	})
+
+	t.Run("Test Set Masked Emails State", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		var requestBody map[string]interface{}
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"methodResponses": []interface{}{
+					[]interface{}{"MaskedEmail/set", map[string]interface{}{
+						"accountId": "fakeAccountID",
+						"updated":   map[string]interface{}{"masked-1": nil},
+					}, "0"},
+				},
+			})
+		})
+
+		require.NoError(t, client.SetMaskedEmailsState(ctx, StateDeleted, "masked-1"))
+
+		methodCall, ok := requestBody["methodCalls"].([]interface{})[0].([]interface{})
+		require.True(t, ok)
+		require.Equal(t, "MaskedEmail/set", methodCall[0])
+		require.Equal(t, map[string]interface{}{
+			"accountId": "fakeAccountID",
+			"update":    map[string]interface{}{"masked-1": map[string]interface{}{"state": "deleted"}},
+		}, methodCall[1])
+	})
+
+	t.Run("Test Update Masked Emails - Not Updated", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		responder, err := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+			"methodResponses": []interface{}{
+				[]interface{}{"MaskedEmail/set", map[string]interface{}{
+					"accountId":  "fakeAccountID",
+					"notUpdated": map[string]interface{}{"masked-2": map[string]interface{}{"type": "notFound"}},
+				}, "0"},
+			},
+		})
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, responder)
+
+		description := ""
+		err = client.UpdateMaskedEmails(ctx, map[string]MaskedEmailUpdate{"masked-2": {Description: &description}})
+
+		var setErrs SetErrors
+		require.ErrorAs(t, err, &setErrs)
+		require.Equal(t, "notFound", setErrs["masked-2"].Type)
+	})
+
+	t.Run("Test Create Masked Emails - Partial Success", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		responder, err := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+			"methodResponses": []interface{}{
+				[]interface{}{"MaskedEmail/set", map[string]interface{}{
+					"accountId":  "fakeAccountID",
+					"created":    map[string]interface{}{"c0": map[string]interface{}{"id": "masked-1", "email": "one@fastmail.com"}},
+					"notCreated": map[string]interface{}{"c1": map[string]interface{}{"type": "invalidProperties"}},
+				}, "0"},
+			},
+		})
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, responder)
+
+		created, err := client.CreateMaskedEmails(ctx, map[string]*MaskedEmail{
+			"c0": {ForDomain: "https://example.com"},
+			"c1": {ForDomain: "invalid"},
+		})
+		require.Equal(t, "masked-1", created["c0"].ID)
+
+		var setErrs SetErrors
+		require.ErrorAs(t, err, &setErrs)
+		require.Len(t, setErrs, 1)
+		require.Equal(t, "invalidProperties", setErrs["c1"].Type)
+	})
 }
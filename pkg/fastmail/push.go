@@ -0,0 +1,240 @@
+package fastmail
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TypeMaskedEmail is the data type of masked emails, as named in StateChange events.
+const TypeMaskedEmail = "MaskedEmail"
+
+const (
+	eventSourcePing    = 30 * time.Second
+	eventSourceTimeout = 3 * eventSourcePing
+	eventStateChange   = "state"
+	maxEventSize       = 1 << 20
+)
+
+var (
+	ErrNoEventSource      = errors.New("session has no event source url")
+	ErrEventSourceTimeout = errors.New("event source stopped responding")
+)
+
+// StateChange is a push notification that the state of data types changed, eg. masked emails were
+// created, updated or destroyed. Changed maps account ID to the new state of each changed type.
+type StateChange struct {
+	Type    string                       `json:"@type" mapstructure:"@type"`
+	Changed map[string]map[string]string `json:"changed" mapstructure:"changed"`
+}
+
+// State returns the new state of a data type in an account, and whether it changed.
+func (s *StateChange) State(accountID, dataType string) (string, bool) {
+	state, ok := s.Changed[accountID][dataType]
+
+	return state, ok
+}
+
+// Subscription is a stream of StateChange events, Events is closed when it ends.
+type Subscription struct {
+	events chan StateChange
+	once   sync.Once
+	err    error
+}
+
+func newSubscription() *Subscription {
+	return &Subscription{events: make(chan StateChange)}
+}
+
+// Events returns the StateChange events, the channel is closed when the subscription ends.
+func (s *Subscription) Events() <-chan StateChange {
+	return s.events
+}
+
+// Err returns why the subscription ended, it is only valid after Events is closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.events)
+	})
+}
+
+// Subscribe connects to the session's EventSource and returns a Subscription to StateChange events
+// of the given types, eg. TypeMaskedEmail, or all types if none are given. The WebSocket is used
+// instead when connected with ConnectWebSocket and the server supports push. The subscription ends
+// when ctx is done or the connection is lost, it is up to the caller to subscribe again.
+func (c *Client) Subscribe(ctx context.Context, types ...string) (*Subscription, error) {
+	if ws := c.webSocket(); ws != nil && ws.supportsPush {
+		return ws.subscribe(ctx, types)
+	}
+
+	session, err := c.GetSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.EventSourceURL == "" {
+		return nil, ErrNoEventSource
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	request := c.httpC.R()
+	request.SetContext(streamCtx)
+	request.SetHeader("Accept", "text/event-stream")
+	request.SetDoNotParseResponse(true)
+
+	res, err := request.Get(eventSourceURL(session.EventSourceURL, types, eventSourcePing))
+	if err == nil {
+		err = checkResponse(c.httpC, res)
+	}
+
+	if err != nil {
+		cancel()
+
+		if res != nil && res.RawBody() != nil {
+			res.RawBody().Close()
+		}
+
+		return nil, fmt.Errorf("event source request failed: %w", err)
+	}
+
+	sub := newSubscription()
+	body := res.RawBody()
+
+	go func() {
+		defer cancel()
+		defer body.Close()
+
+		// The server pings while idle, so a silent connection has been lost.
+		var timedOut int32
+
+		watchdog := time.AfterFunc(eventSourceTimeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+
+			cancel()
+		})
+
+		defer watchdog.Stop()
+
+		err := readEventStream(body, func() { watchdog.Reset(eventSourceTimeout) }, func(event, data string) error {
+			if event != eventStateChange {
+				return nil
+			}
+
+			var change StateChange
+			if err := json.Unmarshal([]byte(data), &change); err != nil {
+				return fmt.Errorf("failed to decode state change: %w", err)
+			}
+
+			select {
+			case sub.events <- change:
+				return nil
+			case <-streamCtx.Done():
+				// nolint:wrapcheck // context error is returned as is.
+				return streamCtx.Err()
+			}
+		})
+
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		case atomic.LoadInt32(&timedOut) == 1:
+			err = ErrEventSourceTimeout
+		case err == nil:
+			err = io.EOF
+		}
+
+		sub.close(err)
+	}()
+
+	return sub, nil
+}
+
+// eventSourceURL expands the session's eventSourceUrl template with the types to subscribe to, or
+// adds them as query parameters when it is not a template.
+func eventSourceURL(template string, types []string, ping time.Duration) string {
+	typesValue := "*"
+	if len(types) > 0 {
+		typesValue = strings.Join(types, ",")
+	}
+
+	pingValue := strconv.Itoa(int(ping.Seconds()))
+
+	if strings.Contains(template, "{") {
+		return strings.NewReplacer("{types}", typesValue, "{closeafter}", "no", "{ping}", pingValue).Replace(template)
+	}
+
+	query := url.Values{"types": {typesValue}, "closeafter": {"no"}, "ping": {pingValue}}
+
+	separator := "?"
+	if strings.Contains(template, "?") {
+		separator = "&"
+	}
+
+	return template + separator + query.Encode()
+}
+
+// readEventStream reads a text/event-stream, calling handle for each event until r ends, and
+// received for each line.
+func readEventStream(r io.Reader, received func(), handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxEventSize)
+
+	var (
+		event string
+		data  []string
+	)
+
+	for scanner.Scan() {
+		received()
+
+		line := scanner.Text()
+
+		if line == "" {
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+
+				if err := handle(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+
+			event, data = "", nil
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	// nolint:wrapcheck // callers wrap errors.
+	return scanner.Err()
+}
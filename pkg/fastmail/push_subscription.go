@@ -0,0 +1,174 @@
+package fastmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	pushTypeStateChange  = "StateChange"
+	pushTypeVerification = "PushVerification"
+)
+
+var ErrUnknownPush = errors.New("unknown push message")
+
+// PushSubscription is a URL the server POSTs StateChange events to. The server first POSTs a
+// PushVerification, the subscription only receives events once verified with VerifyPushSubscription.
+type PushSubscription struct {
+	ID               string   `json:"id,omitempty" mapstructure:"id"`
+	DeviceClientID   string   `json:"deviceClientId,omitempty" mapstructure:"deviceClientId"`
+	URL              string   `json:"url,omitempty" mapstructure:"url"`
+	VerificationCode string   `json:"verificationCode,omitempty" mapstructure:"verificationCode"`
+	Expires          string   `json:"expires,omitempty" mapstructure:"expires"`
+	Types            []string `json:"types,omitempty" mapstructure:"types"`
+}
+
+// PushVerification is POSTed to a new PushSubscription's URL, to prove the client receives it.
+type PushVerification struct {
+	Type               string `json:"@type"`
+	PushSubscriptionID string `json:"pushSubscriptionId"`
+	VerificationCode   string `json:"verificationCode"`
+}
+
+// PushMessage is a message POSTed to a PushSubscription, either a StateChange or a PushVerification.
+type PushMessage struct {
+	StateChange  *StateChange
+	Verification *PushVerification
+}
+
+// PushSubscriptionSetPayload is the payload for the PushSubscription/set method, which unlike other
+// methods does not take an account ID.
+type PushSubscriptionSetPayload struct {
+	Create  map[string]*PushSubscription      `json:"create,omitempty"`
+	Update  map[string]map[string]interface{} `json:"update,omitempty"`
+	Destroy []string                          `json:"destroy,omitempty"`
+}
+
+type MethodResponsePushSubscriptionSet struct {
+	Created      map[string]PushSubscription `mapstructure:"created" json:"created,omitempty"`
+	Updated      map[string]interface{}      `mapstructure:"updated" json:"updated,omitempty"`
+	Destroyed    []string                    `mapstructure:"destroyed" json:"destroyed,omitempty"`
+	NotCreated   map[string]SetError         `mapstructure:"notCreated" json:"notCreated,omitempty"`
+	NotUpdated   map[string]SetError         `mapstructure:"notUpdated" json:"notUpdated,omitempty"`
+	NotDestroyed map[string]SetError         `mapstructure:"notDestroyed" json:"notDestroyed,omitempty"`
+}
+
+// Err returns a SetErrors error if any push subscription could not be created, updated or destroyed.
+func (m *MethodResponsePushSubscriptionSet) Err() error {
+	errs := SetErrors{}
+
+	for _, failed := range []map[string]SetError{m.NotCreated, m.NotUpdated, m.NotDestroyed} {
+		for id, setErr := range failed {
+			errs[id] = setErr
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// CreatePushSubscription asks the server to POST StateChange events of the given types to url,
+// or all types if none are given. deviceClientID identifies this client and device, it should be
+// the same each time the client runs on the device.
+func (c *Client) CreatePushSubscription(ctx context.Context, deviceClientID, url string, types ...string) (*PushSubscription, error) {
+	res, err := c.setPushSubscriptions(ctx, PushSubscriptionSetPayload{
+		Create: map[string]*PushSubscription{
+			c.config.AppName: {DeviceClientID: deviceClientID, URL: url, Types: types},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, ok := res.Created[c.config.AppName]
+	if !ok {
+		return nil, ErrNoItemsReturned
+	}
+
+	return &created, nil
+}
+
+// VerifyPushSubscription sets the verification code POSTed in a PushVerification, after which the
+// server POSTs StateChange events.
+func (c *Client) VerifyPushSubscription(ctx context.Context, id, verificationCode string) error {
+	_, err := c.setPushSubscriptions(ctx, PushSubscriptionSetPayload{
+		Update: map[string]map[string]interface{}{
+			id: {"verificationCode": verificationCode},
+		},
+	})
+
+	return err
+}
+
+// DestroyPushSubscription stops the server POSTing events for the push subscription.
+func (c *Client) DestroyPushSubscription(ctx context.Context, id string) error {
+	_, err := c.setPushSubscriptions(ctx, PushSubscriptionSetPayload{Destroy: []string{id}})
+
+	return err
+}
+
+func (c *Client) setPushSubscriptions(ctx context.Context, payload PushSubscriptionSetPayload) (*MethodResponsePushSubscriptionSet, error) {
+	request := JMAPRequest{
+		Using: []string{CapabilityCore},
+		MethodCalls: []MethodCall{{
+			Name:    "PushSubscription/set",
+			Payload: payload,
+			ID:      "0",
+		}},
+	}
+
+	res, err := c.sendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	var result MethodResponsePushSubscriptionSet
+
+	if err := decodeMethodResponse(res, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, result.Err()
+}
+
+// DecodePushMessage decodes the body of a request POSTed to a push subscription URL.
+func DecodePushMessage(r io.Reader) (*PushMessage, error) {
+	var raw json.RawMessage
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode push: %w", err)
+	}
+
+	var typed struct {
+		Type string `json:"@type"`
+	}
+
+	if err := json.Unmarshal(raw, &typed); err != nil {
+		return nil, fmt.Errorf("failed to decode push: %w", err)
+	}
+
+	switch typed.Type {
+	case pushTypeStateChange:
+		var change StateChange
+		if err := json.Unmarshal(raw, &change); err != nil {
+			return nil, fmt.Errorf("failed to decode state change: %w", err)
+		}
+
+		return &PushMessage{StateChange: &change}, nil
+	case pushTypeVerification:
+		var verification PushVerification
+		if err := json.Unmarshal(raw, &verification); err != nil {
+			return nil, fmt.Errorf("failed to decode push verification: %w", err)
+		}
+
+		return &PushMessage{Verification: &verification}, nil
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrUnknownPush, typed.Type)
+}
@@ -0,0 +1,125 @@
+package fastmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Push_Subscription(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("fastmask")
+	httpmock.ActivateNonDefault(client.httpC.GetClient()) // needed for to mock Resty.
+
+	client.SetTokenAuthCredentials(fakeAccountID, fakeAccessToken)
+
+	ctx := context.TODO()
+
+	// respondSet records the PushSubscription/set arguments and responds with result.
+	respondSet := func(t *testing.T, result map[string]interface{}) *map[string]interface{} {
+		t.Helper()
+
+		var args map[string]interface{}
+
+		httpmock.RegisterResponder(http.MethodPost, APIEndpoint, func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Using       []string        `json:"using"`
+				MethodCalls [][]interface{} `json:"methodCalls"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			require.Equal(t, []string{CapabilityCore}, body.Using)
+			require.Equal(t, "PushSubscription/set", body.MethodCalls[0][0])
+
+			args, _ = body.MethodCalls[0][1].(map[string]interface{})
+
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"methodResponses": []interface{}{[]interface{}{"PushSubscription/set", result, "0"}},
+			})
+		})
+
+		return &args
+	}
+
+	t.Run("Create Push Subscription", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		args := respondSet(t, map[string]interface{}{
+			"created": map[string]interface{}{"fastmask": map[string]interface{}{"id": "push-1", "expires": "2022-06-08T12:00:00Z"}},
+		})
+
+		sub, err := client.CreatePushSubscription(ctx, "device-1", "https://example.com/push", TypeMaskedEmail)
+		require.NoError(t, err)
+		require.Equal(t, "push-1", sub.ID)
+		require.Equal(t, map[string]interface{}{
+			"create": map[string]interface{}{"fastmask": map[string]interface{}{
+				"deviceClientId": "device-1", "url": "https://example.com/push", "types": []interface{}{"MaskedEmail"},
+			}},
+		}, *args)
+	})
+
+	t.Run("Create Push Subscription - Not Created", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		respondSet(t, map[string]interface{}{
+			"notCreated": map[string]interface{}{"fastmask": map[string]interface{}{"type": "invalidProperties"}},
+		})
+
+		_, err := client.CreatePushSubscription(ctx, "device-1", "http://example.com/push")
+
+		var setErrs SetErrors
+		require.ErrorAs(t, err, &setErrs)
+		require.Equal(t, "invalidProperties", setErrs["fastmask"].Type)
+	})
+
+	t.Run("Verify Push Subscription", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		args := respondSet(t, map[string]interface{}{"updated": map[string]interface{}{"push-1": nil}})
+
+		require.NoError(t, client.VerifyPushSubscription(ctx, "push-1", "code-1"))
+		require.Equal(t, map[string]interface{}{
+			"update": map[string]interface{}{"push-1": map[string]interface{}{"verificationCode": "code-1"}},
+		}, *args)
+	})
+
+	t.Run("Destroy Push Subscription", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		args := respondSet(t, map[string]interface{}{"destroyed": []string{"push-1"}})
+
+		require.NoError(t, client.DestroyPushSubscription(ctx, "push-1"))
+		require.Equal(t, map[string]interface{}{"destroy": []interface{}{"push-1"}}, *args)
+	})
+}
+
+func Test_Decode_Push_Message(t *testing.T) {
+	msg, err := DecodePushMessage(strings.NewReader(`{"@type": "PushVerification", "pushSubscriptionId": "push-1", "verificationCode": "code-1"}`))
+	require.NoError(t, err)
+	require.Nil(t, msg.StateChange)
+	require.Equal(t, "code-1", msg.Verification.VerificationCode)
+
+	msg, err = DecodePushMessage(strings.NewReader(`{"@type": "StateChange", "changed": {"account-1": {"MaskedEmail": "43"}}}`))
+	require.NoError(t, err)
+	require.Nil(t, msg.Verification)
+
+	state, ok := msg.StateChange.State("account-1", TypeMaskedEmail)
+	require.True(t, ok)
+	require.Equal(t, "43", state)
+
+	_, err = DecodePushMessage(strings.NewReader(`{"@type": "Unknown"}`))
+	require.ErrorIs(t, err, ErrUnknownPush)
+
+	_, err = DecodePushMessage(strings.NewReader(`not json`))
+	require.Error(t, err)
+}
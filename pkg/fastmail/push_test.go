@@ -0,0 +1,91 @@
+package fastmail
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Subscribe(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("fastmask")
+	httpmock.ActivateNonDefault(client.httpC.GetClient()) // needed for to mock Resty.
+
+	client.SetTokenAuthCredentials(fakeAccountID, fakeAccessToken)
+
+	ctx := context.TODO()
+
+	sessionResponder, err := httpmock.NewJsonResponder(http.StatusOK, httpmock.File("examples/session_response.json"))
+	require.NoError(t, err)
+
+	t.Run("Subscribe", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		httpmock.RegisterResponder(http.MethodGet, APISessionEndpoint, sessionResponder)
+		httpmock.RegisterResponder(http.MethodGet, "https://api.fastmail.com/jmap/event/",
+			func(req *http.Request) (*http.Response, error) {
+				require.Equal(t, "text/event-stream", req.Header.Get("Accept"))
+				require.Equal(t, "MaskedEmail", req.URL.Query().Get("types"))
+
+				return httpmock.NewStringResponse(http.StatusOK, ": comment\n\n"+
+					"event: ping\ndata: {\"interval\":30}\n\n"+
+					"event: state\ndata: {\"@type\":\"StateChange\",\n"+
+					"data: \"changed\":{\""+fakeAccountID+"\":{\"MaskedEmail\":\"43\"}}}\n\n"), nil
+			})
+
+		sub, err := client.Subscribe(ctx, TypeMaskedEmail)
+		require.NoError(t, err)
+
+		var changes []StateChange
+		for change := range sub.Events() {
+			changes = append(changes, change)
+		}
+
+		require.Len(t, changes, 1)
+
+		state, ok := changes[0].State(fakeAccountID, TypeMaskedEmail)
+		require.True(t, ok)
+		require.Equal(t, "43", state)
+		require.ErrorIs(t, sub.Err(), io.EOF)
+	})
+
+	t.Run("Subscribe - Auth Failure", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		httpmock.RegisterResponder(http.MethodGet, APISessionEndpoint, sessionResponder)
+		httpmock.RegisterResponder(http.MethodGet, "https://api.fastmail.com/jmap/event/",
+			httpmock.NewStringResponder(http.StatusUnauthorized, ""))
+
+		_, err := client.Subscribe(ctx, TypeMaskedEmail)
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+}
+
+func Test_Event_Source_URL(t *testing.T) {
+	require.Equal(t, "https://api.fastmail.com/jmap/event/?closeafter=no&ping=30&types=%2A",
+		eventSourceURL("https://api.fastmail.com/jmap/event/", nil, 30*time.Second))
+	require.Equal(t, "https://jmap.example.com/events?types=MaskedEmail,Email&closeafter=no&ping=60",
+		eventSourceURL("https://jmap.example.com/events?types={types}&closeafter={closeafter}&ping={ping}",
+			[]string{"MaskedEmail", "Email"}, time.Minute))
+}
+
+func Test_Read_Event_Stream(t *testing.T) {
+	var events []string
+
+	err := readEventStream(strings.NewReader("data: one\n\nevent: state\ndata: two\ndata: lines\n\nid: 3\n\n"), func() {},
+		func(event, data string) error {
+			events = append(events, event+"="+data)
+
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"message=one", "state=two\nlines"}, events)
+}
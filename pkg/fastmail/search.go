@@ -0,0 +1,327 @@
+package fastmail
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fields searched by SearchMaskedEmails.
+const (
+	SearchFieldDomain      = "forDomain"
+	SearchFieldDescription = "description"
+	SearchFieldEmail       = "email"
+	SearchFieldURL         = "url"
+)
+
+// Scores for how well a query term matches a word, before weighting by field.
+const (
+	scoreExact       = 1.0
+	scorePrefix      = 0.9
+	scoreSubstring   = 0.75
+	scoreTypo        = 0.6
+	scoreSubsequence = 0.3
+
+	// Terms shorter than this must match without typos.
+	minTypoTermLen = 4
+	// Terms of at least this length may contain two typos.
+	twoTypoTermLen = 7
+	// Shorter terms are not matched as a subsequence, they would match most words.
+	minSubsequenceTermLen = 3
+)
+
+var searchFieldWeights = map[string]float64{
+	SearchFieldDomain:      1.0,
+	SearchFieldDescription: 1.0,
+	SearchFieldEmail:       0.8,
+	SearchFieldURL:         0.6,
+}
+
+// Span is a byte range of a matched part of a field, End is exclusive.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchResult is a masked email matching a search query.
+type SearchResult struct {
+	MaskedEmail MaskedEmail `json:"maskedEmail"`
+	Score       float64     `json:"score"`
+	// Matches are the matched spans of each field, keyed by the field name, eg. SearchFieldDomain.
+	Matches map[string][]Span `json:"matches"`
+}
+
+// SearchMaskedEmails returns the masked emails matching every term of the query, ranked best
+// match first. Matching is case-insensitive and tolerates typos, eg. 'newsleter' matches
+// 'newsletters'.
+func SearchMaskedEmails(maskedEmails []MaskedEmail, query string) []SearchResult {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []SearchResult
+
+	for i := range maskedEmails {
+		if result, ok := searchMaskedEmail(&maskedEmails[i], terms); ok {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		return results[i].MaskedEmail.Email < results[j].MaskedEmail.Email
+	})
+
+	return results
+}
+
+func searchMaskedEmail(m *MaskedEmail, terms []token) (SearchResult, bool) {
+	fields := map[string][]token{
+		SearchFieldDomain:      tokenize(m.ForDomain),
+		SearchFieldDescription: tokenize(m.Description),
+		SearchFieldEmail:       tokenize(m.Email),
+		SearchFieldURL:         tokenize(m.URL),
+	}
+
+	result := SearchResult{MaskedEmail: *m, Matches: map[string][]Span{}}
+
+	for _, term := range terms {
+		var (
+			bestScore float64
+			bestField string
+			bestSpan  Span
+		)
+
+		for field, words := range fields {
+			for _, word := range words {
+				score, span := matchTerm(term, word)
+				score *= searchFieldWeights[field]
+
+				// Prefer fields in a fixed order on equal scores so results are stable.
+				if score > bestScore || (score == bestScore && score > 0 && field < bestField) {
+					bestScore, bestField, bestSpan = score, field, span
+				}
+			}
+		}
+
+		// Every term must match.
+		if bestScore == 0 {
+			return SearchResult{}, false
+		}
+
+		result.Score += bestScore
+		result.Matches[bestField] = append(result.Matches[bestField], bestSpan)
+	}
+
+	for field := range result.Matches {
+		result.Matches[field] = mergeSpans(result.Matches[field])
+	}
+
+	return result, true
+}
+
+// token is a word of a field, lowercased, with its byte range in the original text.
+type token struct {
+	text  string
+	start int
+	end   int
+	// exact is true when the lowercased text has the same byte layout as the original.
+	exact bool
+}
+
+// tokenize splits s into words of letters and digits.
+func tokenize(s string) []token {
+	var (
+		tokens []token
+		start  = -1
+	)
+
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+
+		original := s[start:end]
+		lower := strings.ToLower(original)
+		tokens = append(tokens, token{text: lower, start: start, end: end, exact: len(lower) == len(original)})
+		start = -1
+	}
+
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+
+			continue
+		}
+
+		flush(i)
+	}
+
+	flush(len(s))
+
+	return tokens
+}
+
+// matchTerm returns how well term matches word, and the span of word that matched.
+func matchTerm(term, word token) (float64, Span) {
+	whole := Span{Start: word.start, End: word.end}
+
+	switch {
+	case term.text == word.text:
+		return scoreExact, whole
+	case strings.HasPrefix(word.text, term.text):
+		return scorePrefix, partialSpan(word, 0, len(term.text))
+	}
+
+	if i := strings.Index(word.text, term.text); i >= 0 {
+		return scoreSubstring, partialSpan(word, i, i+len(term.text))
+	}
+
+	termLen := utf8.RuneCountInString(term.text)
+
+	if maxEdits := allowedEdits(termLen); maxEdits > 0 {
+		distance := editDistance(term.text, word.text)
+
+		// Also compare to the start of longer words, so typos in a prefix still match.
+		if prefix := runePrefix(word.text, termLen); prefix != word.text {
+			if d := editDistance(term.text, prefix); d < distance {
+				distance = d
+			}
+		}
+
+		if distance <= maxEdits {
+			return scoreTypo * (1 - float64(distance)/float64(termLen+1)), whole
+		}
+	}
+
+	if termLen >= minSubsequenceTermLen && isSubsequence(term.text, word.text) {
+		return scoreSubsequence, whole
+	}
+
+	return 0, Span{}
+}
+
+func allowedEdits(termLen int) int {
+	switch {
+	case termLen < minTypoTermLen:
+		return 0
+	case termLen < twoTypoTermLen:
+		return 1
+	default:
+		return 2 // nolint:gomnd // two typos allowed in long terms.
+	}
+}
+
+// partialSpan returns the span of word from byte i to j of its lowercased text, falling back to
+// the whole word when lowercasing changed the byte layout.
+func partialSpan(word token, i, j int) Span {
+	if !word.exact {
+		return Span{Start: word.start, End: word.end}
+	}
+
+	return Span{Start: word.start + i, End: word.start + j}
+}
+
+func runePrefix(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+
+		n--
+	}
+
+	return s
+}
+
+// editDistance returns the optimal string alignment distance between a and b, counting
+// insertions, deletions, substitutions and transpositions of adjacent characters.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// isSubsequence returns true if all runes of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	rs := []rune(s)
+	i := 0
+
+	for _, r := range sub {
+		for i < len(rs) && rs[i] != r {
+			i++
+		}
+
+		if i == len(rs) {
+			return false
+		}
+
+		i++
+	}
+
+	return true
+}
+
+// mergeSpans sorts spans and merges any that overlap.
+func mergeSpans(spans []Span) []Span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+
+	merged := spans[:0]
+
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span.Start <= merged[n-1].End {
+			if span.End > merged[n-1].End {
+				merged[n-1].End = span.End
+			}
+
+			continue
+		}
+
+		merged = append(merged, span)
+	}
+
+	return merged
+}
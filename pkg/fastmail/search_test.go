@@ -0,0 +1,52 @@
+package fastmail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Search_Masked_Emails(t *testing.T) {
+	maskedEmails := []MaskedEmail{
+		{ID: "masked-1", Email: "test.example1234@fastmail.com", ForDomain: "https://www.example.com", Description: "avoiding endless newsletters."},
+		{ID: "masked-2", Email: "other.thing5678@fastmail.com", ForDomain: "https://shop.example.org", URL: "https://shop.example.org/login"},
+		{ID: "masked-3", Email: "pending.mask9999@fastmail.com", ForDomain: "news.example.net", Description: "Newsletter"},
+	}
+
+	ids := func(results []SearchResult) []string {
+		list := make([]string, 0, len(results))
+		for i := range results {
+			list = append(list, results[i].MaskedEmail.ID)
+		}
+
+		return list
+	}
+
+	t.Run("Exact Word Ranks First", func(t *testing.T) {
+		results := SearchMaskedEmails(maskedEmails, "newsletter")
+		require.Equal(t, []string{"masked-3", "masked-1"}, ids(results))
+		require.Equal(t, []Span{{Start: 0, End: 10}}, results[0].Matches[SearchFieldDescription])
+	})
+
+	t.Run("Typo Tolerant", func(t *testing.T) {
+		results := SearchMaskedEmails(maskedEmails, "endles newsleters")
+		require.Equal(t, []string{"masked-1"}, ids(results))
+	})
+
+	t.Run("Prefix Span", func(t *testing.T) {
+		results := SearchMaskedEmails(maskedEmails, "sho")
+		require.Equal(t, []string{"masked-2"}, ids(results))
+		require.Equal(t, []Span{{Start: 8, End: 11}}, results[0].Matches[SearchFieldDomain])
+	})
+
+	t.Run("All Terms Must Match", func(t *testing.T) {
+		require.Empty(t, SearchMaskedEmails(maskedEmails, "shop newsletter"))
+		require.Empty(t, SearchMaskedEmails(maskedEmails, "   "))
+	})
+
+	t.Run("Edit Distance", func(t *testing.T) {
+		require.Equal(t, 0, editDistance("abc", "abc"))
+		require.Equal(t, 1, editDistance("abc", "acb"), "transposition counts as one edit")
+		require.Equal(t, 2, editDistance("kitten", "sittin"))
+	})
+}
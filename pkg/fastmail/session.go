@@ -0,0 +1,63 @@
+package fastmail
+
+import (
+	"context"
+	"fmt"
+)
+
+// Session is the JMAP session resource, describing the authenticated user, their accounts
+// and the capabilities of the server.
+type Session struct {
+	Username        string                 `json:"username"`
+	DisplayName     string                 `json:"displayName,omitempty"`
+	APIURL          string                 `json:"apiUrl"`
+	DownloadURL     string                 `json:"downloadUrl,omitempty"`
+	UploadURL       string                 `json:"uploadUrl,omitempty"`
+	EventSourceURL  string                 `json:"eventSourceUrl,omitempty"`
+	State           string                 `json:"state"`
+	Capabilities    map[string]interface{} `json:"capabilities,omitempty"`
+	PrimaryAccounts map[string]string      `json:"primaryAccounts,omitempty"`
+	Accounts        map[string]Account     `json:"accounts,omitempty"`
+}
+
+// Account is an account the authenticated user has access to.
+type Account struct {
+	Name                string                 `json:"name"`
+	IsPersonal          bool                   `json:"isPersonal"`
+	IsReadOnly          bool                   `json:"isReadOnly"`
+	AccountCapabilities map[string]interface{} `json:"accountCapabilities,omitempty"`
+}
+
+// HasCapability returns true if the server supports the given capability.
+func (s *Session) HasCapability(capability string) bool {
+	_, ok := s.Capabilities[capability]
+
+	return ok
+}
+
+// AccountHasCapability returns true if the given account supports the given capability.
+func (s *Session) AccountHasCapability(accountID, capability string) bool {
+	account, ok := s.Accounts[accountID]
+	if !ok {
+		return false
+	}
+
+	_, ok = account.AccountCapabilities[capability]
+
+	return ok
+}
+
+// GetSession returns the JMAP session for the current credentials.
+func (c *Client) GetSession(ctx context.Context) (*Session, error) {
+	var session Session
+
+	request := c.httpC.R()
+	request.SetContext(ctx)
+	request.SetResult(&session)
+
+	if _, err := request.Get(c.config.SessionURL); err != nil {
+		return nil, fmt.Errorf("get session failed: %w", err)
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,51 @@
+package fastmail
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Session(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(fake.CharactersN(10))
+	httpmock.ActivateNonDefault(client.httpC.GetClient()) // needed for to mock Resty.
+
+	client.SetTokenAuthCredentials(fakeAccountID, fakeAccessToken)
+
+	ctx := context.TODO()
+
+	t.Run("Get Session", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		sessionResponder, err := httpmock.NewJsonResponder(http.StatusOK, httpmock.File("examples/session_response.json"))
+		require.NoError(t, err)
+
+		httpmock.RegisterResponder(http.MethodGet, APISessionEndpoint, sessionResponder)
+
+		session, err := client.GetSession(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, session)
+		require.Equal(t, "nobody@fastmail.com", session.Username)
+		require.Equal(t, fakeAccountID, session.PrimaryAccounts[CapabilityMaskedEmail])
+		require.True(t, session.HasCapability(CapabilityMaskedEmail))
+		require.True(t, session.AccountHasCapability(fakeAccountID, CapabilityMaskedEmail))
+		require.False(t, session.AccountHasCapability("unknown", CapabilityMaskedEmail))
+	})
+
+	t.Run("Get Session - Auth Failure", func(t *testing.T) {
+		defer httpmock.Reset()
+
+		httpmock.RegisterResponder(http.MethodGet, APISessionEndpoint, httpmock.NewStringResponder(http.StatusUnauthorized, ""))
+
+		session, err := client.GetSession(ctx)
+		require.ErrorIs(t, err, ErrUnauthorized)
+		require.Nil(t, session)
+	})
+}
@@ -3,6 +3,8 @@ package fastmail
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/mitchellh/mapstructure"
 )
 
 type JMAPRequest struct {
@@ -64,6 +66,33 @@ type MethodResponseMaskedEmailSet struct {
 	Destroyed []interface{}          `mapstructure:"destroyed" json:"destroyed,omitempty"`
 	NewState  interface{}            `mapstructure:"newState" json:"newState,omitempty"`
 	OldState  interface{}            `mapstructure:"oldState" json:"oldState,omitempty"`
+
+	NotCreated   map[string]SetError `mapstructure:"notCreated" json:"notCreated,omitempty"`
+	NotUpdated   map[string]SetError `mapstructure:"notUpdated" json:"notUpdated,omitempty"`
+	NotDestroyed map[string]SetError `mapstructure:"notDestroyed" json:"notDestroyed,omitempty"`
+}
+
+// SetError is the reason a masked email could not be created, updated or destroyed.
+type SetError struct {
+	Type        string `mapstructure:"type" json:"type"`
+	Description string `mapstructure:"description" json:"description,omitempty"`
+}
+
+// Err returns a SetErrors error if any masked email could not be created, updated or destroyed.
+func (m *MethodResponseMaskedEmailSet) Err() error {
+	errs := SetErrors{}
+
+	for _, failed := range []map[string]SetError{m.NotCreated, m.NotUpdated, m.NotDestroyed} {
+		for id, setErr := range failed {
+			errs[id] = setErr
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
 }
 
 func (m *MethodResponseMaskedEmailSet) GetCreatedItem() (MaskedEmail, error) {
@@ -73,3 +102,53 @@ func (m *MethodResponseMaskedEmailSet) GetCreatedItem() (MaskedEmail, error) {
 
 	return MaskedEmail{}, ErrNoItemsReturned
 }
+
+type MethodResponseMaskedEmailGet struct {
+	AccountID string        `mapstructure:"accountId" json:"accountId,omitempty"`
+	State     string        `mapstructure:"state" json:"state,omitempty"`
+	List      []MaskedEmail `mapstructure:"list" json:"list,omitempty"`
+	NotFound  []string      `mapstructure:"notFound" json:"notFound,omitempty"`
+	// SessionState is the session state of the response, it changes when the session does, eg.
+	// when accounts or capabilities change.
+	SessionState string `mapstructure:"-" json:"-"`
+}
+
+// methodErrorPayload is the payload of an 'error' method response.
+type methodErrorPayload struct {
+	Type        string `mapstructure:"type" json:"type"`
+	Description string `mapstructure:"description" json:"description,omitempty"`
+}
+
+// decodeMethodResponse checks the response contains a single method response and decodes its
+// payload into out, returning a MethodError if the server responded with an error.
+func decodeMethodResponse(res *JMAPResponse, out interface{}) error {
+	// nolint:gomnd // ignore here.
+	if len(res.MethodResponses) != 1 {
+		return MethodResponseError{len(res.MethodResponses), 1}
+	}
+
+	return decodeMethodResponseAt(res, 0, out)
+}
+
+// decodeMethodResponseAt decodes the payload of the method response at index i into out.
+func decodeMethodResponseAt(res *JMAPResponse, i int, out interface{}) error {
+	if i >= len(res.MethodResponses) {
+		return MethodResponseError{len(res.MethodResponses), i + 1}
+	}
+
+	if name, _ := res.MethodResponses[i][0].(string); name == "error" {
+		var methodErr methodErrorPayload
+
+		if err := mapstructure.Decode(res.MethodResponses[i][1], &methodErr); err != nil {
+			return fmt.Errorf("error decoding error response: %w", err)
+		}
+
+		return MethodError{Type: methodErr.Type, Description: methodErr.Description}
+	}
+
+	if err := mapstructure.Decode(res.MethodResponses[i][1], out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return nil
+}
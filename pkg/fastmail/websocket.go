@@ -0,0 +1,386 @@
+package fastmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/mitchellh/mapstructure"
+)
+
+// CapabilityWebSocket is the JMAP WebSocket capability (RFC 8887).
+const CapabilityWebSocket = "urn:ietf:params:jmap:websocket"
+
+const webSocketSubprotocol = "jmap"
+
+var (
+	ErrNoWebSocket     = errors.New("server does not support jmap over websocket")
+	ErrWebSocketPush   = errors.New("server does not support push over websocket")
+	errWebSocketClosed = errors.New("websocket closed")
+)
+
+// webSocketCapability is the session's CapabilityWebSocket value.
+type webSocketCapability struct {
+	URL          string `mapstructure:"url"`
+	SupportsPush bool   `mapstructure:"supportsPush"`
+}
+
+// webSocketRequest is a JMAP request sent over a WebSocket, the response has the same ID.
+type webSocketRequest struct {
+	Type string `json:"@type"`
+	ID   string `json:"id"`
+	*JMAPRequest
+}
+
+// webSocketPushEnable asks the server to push StateChange events for the data types, or all types
+// when DataTypes is nil.
+type webSocketPushEnable struct {
+	Type      string   `json:"@type"`
+	DataTypes []string `json:"dataTypes"`
+}
+
+// webSocketMessage is any message received from the server: a Response, a RequestError or a
+// StateChange, told apart by Type.
+type webSocketMessage struct {
+	Type      string `json:"@type"`
+	RequestID string `json:"requestId"`
+	JMAPResponse
+	Changed map[string]map[string]string `json:"changed"`
+	// ErrorType, Status and Detail describe a RequestError.
+	ErrorType string `json:"type"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+}
+
+type webSocketResult struct {
+	res *JMAPResponse
+	err error
+}
+
+// webSocketConn multiplexes JMAP requests over a WebSocket, matching responses to requests by ID,
+// and delivers pushed StateChange events to the active subscription.
+type webSocketConn struct {
+	conn         *websocket.Conn
+	supportsPush bool
+	writeMu      sync.Mutex
+	nextID       uint64
+
+	mu      sync.Mutex
+	pending map[string]chan webSocketResult
+	sub     *webSocketSubscription
+	err     error
+	done    chan struct{}
+}
+
+// webSocketSubscription queues pushed events so the read loop never waits for the subscriber.
+type webSocketSubscription struct {
+	*Subscription
+	ctx    context.Context
+	mu     sync.Mutex
+	queue  []StateChange
+	notify chan struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	stopErr  error
+}
+
+// ConnectWebSocket connects to the server's JMAP WebSocket endpoint, after which requests and
+// subscriptions use the WebSocket instead of HTTP. If the server does not support it, or the
+// connection is lost, HTTP is used. It must not be called concurrently with other requests.
+func (c *Client) ConnectWebSocket(ctx context.Context) error {
+	session, err := c.GetSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	value, ok := session.Capabilities[CapabilityWebSocket]
+	if !ok {
+		return ErrNoWebSocket
+	}
+
+	var capability webSocketCapability
+	if err := mapstructure.Decode(value, &capability); err != nil || capability.URL == "" {
+		return ErrNoWebSocket
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: c.httpC.GetClient().Timeout,
+		Subprotocols:     []string{webSocketSubprotocol},
+	}
+
+	header := http.Header{}
+	if c.creds != nil {
+		header.Set("Authorization", "Bearer "+c.creds.accessToken)
+	}
+
+	conn, res, err := dialer.DialContext(ctx, capability.URL, header)
+	if res != nil && res.Body != nil {
+		res.Body.Close()
+	}
+
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusUnauthorized {
+			return ErrUnauthorized
+		}
+
+		return fmt.Errorf("websocket connect failed: %w", err)
+	}
+
+	c.CloseWebSocket()
+
+	ws := &webSocketConn{
+		conn:         conn,
+		supportsPush: capability.SupportsPush,
+		pending:      map[string]chan webSocketResult{},
+		done:         make(chan struct{}),
+	}
+
+	go ws.readLoop()
+
+	c.ws = ws
+
+	return nil
+}
+
+// CloseWebSocket closes the WebSocket connection, later requests use HTTP.
+func (c *Client) CloseWebSocket() {
+	if c.ws != nil {
+		c.ws.close(errWebSocketClosed)
+		c.ws = nil
+	}
+}
+
+// webSocket returns the WebSocket connection, or nil if it is not connected.
+func (c *Client) webSocket() *webSocketConn {
+	if c.ws == nil {
+		return nil
+	}
+
+	select {
+	case <-c.ws.done:
+		return nil
+	default:
+		return c.ws
+	}
+}
+
+func (ws *webSocketConn) send(ctx context.Context, r *JMAPRequest) (*JMAPResponse, error) {
+	id := strconv.FormatUint(atomic.AddUint64(&ws.nextID, 1), 10)
+	result := make(chan webSocketResult, 1)
+
+	ws.mu.Lock()
+	if ws.err != nil {
+		ws.mu.Unlock()
+
+		return nil, ws.err
+	}
+
+	ws.pending[id] = result
+	ws.mu.Unlock()
+
+	defer func() {
+		ws.mu.Lock()
+		delete(ws.pending, id)
+		ws.mu.Unlock()
+	}()
+
+	if err := ws.write(webSocketRequest{Type: "Request", ID: id, JMAPRequest: r}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-result:
+		return res.res, res.err
+	case <-ctx.Done():
+		// nolint:wrapcheck // context error is returned as is.
+		return nil, ctx.Err()
+	}
+}
+
+func (ws *webSocketConn) write(v interface{}) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	if err := ws.conn.WriteJSON(v); err != nil {
+		ws.close(fmt.Errorf("%w: %s", errWebSocketClosed, err.Error()))
+
+		return fmt.Errorf("%w: %s", errWebSocketClosed, err.Error())
+	}
+
+	return nil
+}
+
+func (ws *webSocketConn) readLoop() {
+	for {
+		var msg webSocketMessage
+
+		if err := ws.conn.ReadJSON(&msg); err != nil {
+			ws.close(fmt.Errorf("%w: %s", errWebSocketClosed, err.Error()))
+
+			return
+		}
+
+		switch msg.Type {
+		case "Response", "RequestError":
+			ws.mu.Lock()
+			result, ok := ws.pending[msg.RequestID]
+			ws.mu.Unlock()
+
+			if !ok {
+				continue
+			}
+
+			if msg.Type == "RequestError" {
+				result <- webSocketResult{err: APIError{Msg: msg.ErrorType, Code: msg.Status, Status: http.StatusText(msg.Status), Detail: msg.Detail}}
+
+				continue
+			}
+
+			res := msg.JMAPResponse
+			result <- webSocketResult{res: &res}
+		case "StateChange":
+			ws.mu.Lock()
+			sub := ws.sub
+			ws.mu.Unlock()
+
+			if sub != nil {
+				sub.push(StateChange{Type: msg.Type, Changed: msg.Changed})
+			}
+		}
+	}
+}
+
+// close ends the connection, failing pending requests and the subscription with err.
+func (ws *webSocketConn) close(err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.err != nil {
+		return
+	}
+
+	ws.err = err
+	close(ws.done)
+	ws.conn.Close()
+
+	for _, result := range ws.pending {
+		select {
+		case result <- webSocketResult{err: err}:
+		default:
+		}
+	}
+
+	if ws.sub != nil {
+		ws.sub.end(err)
+	}
+}
+
+// subscribe enables push for the types, replacing the previous subscription.
+func (ws *webSocketConn) subscribe(ctx context.Context, types []string) (*Subscription, error) {
+	if !ws.supportsPush {
+		return nil, ErrWebSocketPush
+	}
+
+	sub := &webSocketSubscription{
+		Subscription: newSubscription(),
+		ctx:          ctx,
+		notify:       make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+
+	ws.mu.Lock()
+	if ws.err != nil {
+		ws.mu.Unlock()
+
+		return nil, ws.err
+	}
+
+	previous := ws.sub
+	ws.sub = sub
+	ws.mu.Unlock()
+
+	if previous != nil {
+		previous.end(context.Canceled)
+	}
+
+	if err := ws.write(webSocketPushEnable{Type: "WebSocketPushEnable", DataTypes: types}); err != nil {
+		return nil, err
+	}
+
+	go sub.run(ws)
+
+	return sub.Subscription, nil
+}
+
+func (s *webSocketSubscription) push(change StateChange) {
+	s.mu.Lock()
+	s.queue = append(s.queue, change)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// end stops the subscription with err, once queued events are delivered.
+func (s *webSocketSubscription) end(err error) {
+	s.stopOnce.Do(func() {
+		s.stopErr = err
+		close(s.stop)
+	})
+}
+
+// run delivers queued events until the subscription ends, or ctx is done. It is the only sender on
+// the events channel, so it closes it.
+func (s *webSocketSubscription) run(ws *webSocketConn) {
+	defer func() {
+		ws.mu.Lock()
+		if ws.sub == s {
+			ws.sub = nil
+		}
+		ws.mu.Unlock()
+	}()
+
+	for {
+		var stopped bool
+
+		select {
+		case <-s.notify:
+		case <-s.stop:
+			stopped = true
+		case <-s.ctx.Done():
+			s.close(s.ctx.Err())
+
+			return
+		}
+
+		s.mu.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, change := range queue {
+			select {
+			case s.events <- change:
+			case <-s.ctx.Done():
+				s.close(s.ctx.Err())
+
+				return
+			}
+		}
+
+		if stopped {
+			s.close(s.stopErr)
+
+			return
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package fastmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// maskedEmailGetResponse returns a MaskedEmail/get response with the state set to the first ID
+// requested, so tests can tell which request it answers and over which transport.
+func maskedEmailGetResponse(t *testing.T, r *JMAPRequest, prefix string) JMAPResponse {
+	t.Helper()
+
+	payload, err := json.Marshal(r.MethodCalls[0].Payload)
+	require.NoError(t, err)
+
+	var get MaskedEmailGetPayload
+	require.NoError(t, json.Unmarshal(payload, &get))
+
+	return JMAPResponse{MethodResponses: []MethodResponse{{
+		"MaskedEmail/get",
+		map[string]interface{}{"accountId": get.AccountID, "state": prefix + strings.Join(get.IDs, ","), "list": []interface{}{}},
+		r.MethodCalls[0].ID,
+	}}}
+}
+
+func newWebSocketTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(Session{
+			APIURL: server.URL + "/api",
+			Capabilities: map[string]interface{}{
+				CapabilityCore:      map[string]interface{}{},
+				CapabilityWebSocket: map[string]interface{}{"url": "ws" + strings.TrimPrefix(server.URL, "http") + "/ws", "supportsPush": true},
+			},
+		}))
+	})
+
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		var request JMAPRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(maskedEmailGetResponse(t, &request, "http:")))
+	})
+
+	upgrader := websocket.Upgrader{Subprotocols: []string{webSocketSubprotocol}}
+
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+fakeAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+
+		defer conn.Close()
+
+		var held []webSocketRequest
+
+		for {
+			var msg struct {
+				Type string `json:"@type"`
+				ID   string `json:"id"`
+				JMAPRequest
+			}
+
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+
+			switch msg.Type {
+			case "WebSocketPushEnable":
+				require.NoError(t, conn.WriteJSON(map[string]interface{}{
+					"@type":   "StateChange",
+					"changed": map[string]interface{}{fakeAccountID: map[string]string{TypeMaskedEmail: "43"}},
+				}))
+			case "Request":
+				request := msg.JMAPRequest
+				response := maskedEmailGetResponse(t, &request, "ws:")
+
+				if response.MethodResponses[0][1].(map[string]interface{})["state"] == "ws:close" {
+					return
+				}
+
+				// Answer pairs of requests in reverse order, responses are matched by request ID.
+				held = append(held, webSocketRequest{Type: msg.Type, ID: msg.ID, JMAPRequest: &request})
+				if len(held) < 2 {
+					continue
+				}
+
+				for i := len(held) - 1; i >= 0; i-- {
+					res := maskedEmailGetResponse(t, held[i].JMAPRequest, "ws:")
+					require.NoError(t, conn.WriteJSON(map[string]interface{}{
+						"@type":           "Response",
+						"requestId":       held[i].ID,
+						"methodResponses": res.MethodResponses,
+						"sessionState":    "session-1",
+					}))
+				}
+
+				held = nil
+			}
+		}
+	})
+
+	server = httptest.NewServer(mux)
+
+	return server
+}
+
+func Test_WebSocket(t *testing.T) {
+	server := newWebSocketTestServer(t)
+	defer server.Close()
+
+	client := NewClient("fastmask")
+	client.config.SessionURL = server.URL + "/session"
+	client.config.APIBaseURL = server.URL + "/api"
+
+	ctx := context.TODO()
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		client.SetTokenAuthCredentials(fakeAccountID, "wrong")
+		require.ErrorIs(t, client.ConnectWebSocket(ctx), ErrUnauthorized)
+	})
+
+	client.SetTokenAuthCredentials(fakeAccountID, fakeAccessToken)
+	require.NoError(t, client.ConnectWebSocket(ctx))
+
+	defer client.CloseWebSocket()
+
+	t.Run("Multiplexed Requests", func(t *testing.T) {
+		var wg sync.WaitGroup
+
+		for _, id := range []string{"masked-1", "masked-2"} {
+			wg.Add(1)
+
+			go func(id string) {
+				defer wg.Done()
+
+				result, err := client.GetMaskedEmailList(ctx, id)
+				require.NoError(t, err)
+				require.Equal(t, "ws:"+id, result.State)
+				require.Equal(t, "session-1", result.SessionState)
+			}(id)
+		}
+
+		wg.Wait()
+	})
+
+	t.Run("Push", func(t *testing.T) {
+		sub, err := client.Subscribe(ctx, TypeMaskedEmail)
+		require.NoError(t, err)
+
+		change := <-sub.Events()
+
+		state, ok := change.State(fakeAccountID, TypeMaskedEmail)
+		require.True(t, ok)
+		require.Equal(t, "43", state)
+
+		// Losing the connection ends the subscription, and requests fall back to HTTP.
+		result, err := client.GetMaskedEmailList(ctx, "close")
+		require.NoError(t, err)
+		require.Equal(t, "http:close", result.State)
+
+		for range sub.Events() {
+		}
+
+		require.ErrorIs(t, sub.Err(), errWebSocketClosed)
+		require.Nil(t, client.webSocket())
+	})
+}